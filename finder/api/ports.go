@@ -1,11 +1,17 @@
 package api
 
 import (
+	"context"
+
 	"gospiga/finder/fulltext"
+	"gospiga/pkg/streamer"
 )
 
 type App interface {
 	SearchRecipes(string) ([]*fulltext.Recipe, error)
 	SearchByTag([]string) ([]*fulltext.Recipe, error)
 	AllRecipeTags() ([]string, error)
+	StreamsInfo([]string) ([]*streamer.StreamInfo, error)
+	SetGroupID(stream, group, id string) error
+	RebuildIndex(context.Context) (map[string]*streamer.ReplayStats, error)
 }
@@ -1,7 +1,12 @@
 package api
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+
+	errs "gospiga/pkg/errors"
+	"gospiga/pkg/httperr"
 )
 
 type SearchRequest struct {
@@ -10,16 +15,18 @@ type SearchRequest struct {
 
 func (s *GospigaService) SearchRecipes(c *gin.Context) {
 	var req SearchRequest
-	err := c.BindJSON(&req)
+	err := c.ShouldBindJSON(&req)
 	if err != nil {
-		c.Error(err)
+		httperr.Abort(c, errs.Validation(err))
+		return
 	}
 
 	recipes, err := s.app.SearchRecipes(req.Query)
 	if err != nil {
-		c.Error(err)
+		httperr.Abort(c, err)
+		return
 	}
-	c.JSON(200, gin.H{"recipes": recipes})
+	c.JSON(http.StatusOK, gin.H{"recipes": recipes})
 }
 
 type TagRequest struct {
@@ -28,22 +35,25 @@ type TagRequest struct {
 
 func (s *GospigaService) SearchByTag(c *gin.Context) {
 	var req TagRequest
-	err := c.BindJSON(&req)
+	err := c.ShouldBindJSON(&req)
 	if err != nil {
-		c.Error(err)
+		httperr.Abort(c, errs.Validation(err))
+		return
 	}
 
 	recipes, err := s.app.SearchByTag(req.Tags)
 	if err != nil {
-		c.Error(err)
+		httperr.Abort(c, err)
+		return
 	}
-	c.JSON(200, gin.H{"recipes": recipes})
+	c.JSON(http.StatusOK, gin.H{"recipes": recipes})
 }
 
 func (s *GospigaService) AllRecipeTags(c *gin.Context) {
 	tags, err := s.app.AllRecipeTags()
 	if err != nil {
-		c.Error(err)
+		httperr.Abort(c, err)
+		return
 	}
-	c.JSON(200, gin.H{"tags": tags})
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
 }
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	errs "gospiga/pkg/errors"
+	"gospiga/pkg/httperr"
+	"gospiga/pkg/streamer"
+)
+
+// StreamsInfo reports the state of the streams and of their consumer groups.
+func (s *GospigaService) StreamsInfo(c *gin.Context) {
+	infos, err := s.app.StreamsInfo(c.QueryArray("stream"))
+	if err != nil {
+		httperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"streams": infos})
+}
+
+// RewindRequest moves a consumer group to an entry ID or back to a point in
+// time.
+type RewindRequest struct {
+	Group string    `json:"group"`
+	ID    string    `json:"id"`
+	Since time.Time `json:"since"`
+}
+
+// groupID returns the ID to move the group to.
+func (r *RewindRequest) groupID() (string, error) {
+	switch {
+	case r.ID != "" && !r.Since.IsZero():
+		return "", errors.New("either id or since must be given, not both")
+	case r.ID != "":
+		return r.ID, nil
+	case !r.Since.IsZero():
+		return streamer.TimeID(r.Since), nil
+	}
+	return "", errors.New("missing id or since")
+}
+
+// Rewind moves a consumer group on the stream, so that the entries after the
+// given position are delivered again.
+func (s *GospigaService) Rewind(c *gin.Context) {
+	var req RewindRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		httperr.Abort(c, errs.Validation(err))
+		return
+	}
+	id, err := req.groupID()
+	if err != nil {
+		httperr.Abort(c, errs.Validation(err))
+		return
+	}
+
+	err = s.app.SetGroupID(c.Param("stream"), req.Group, id)
+	if err != nil {
+		httperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// RebuildIndex indexes again the recipes from the stream history.
+func (s *GospigaService) RebuildIndex(c *gin.Context) {
+	stats, err := s.app.RebuildIndex(c.Copy().Request.Context())
+	if err != nil {
+		httperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"replayed": stats})
+}
@@ -22,7 +22,10 @@ import (
 	"gospiga/finder/fulltext"
 	gogrpc "gospiga/finder/grpc"
 	"gospiga/finder/usecase"
+	"gospiga/pkg/auth"
+	"gospiga/pkg/httperr"
 	"gospiga/pkg/log"
+	"gospiga/pkg/nats"
 	"gospiga/pkg/redis"
 	"gospiga/pkg/streamer"
 	pb "gospiga/proto"
@@ -74,15 +77,40 @@ func main() {
 	}
 
 	db := db.NewRedisDB(rdb)
-	streamer, err := streamer.NewRedisStreamer(rdb)
-	if err != nil {
-		log.Fatalf("error initializing redis streamer: %s", err)
+	var st usecase.Streamer
+	switch viper.GetString("streamer.backend") {
+	case "memory":
+		log.Infof("using in-memory streamer, messages won't survive restarts")
+		st = streamer.NewMemoryStreamer()
+	case "nats":
+		nc, err := nats.NewConn(viper.GetString("streamer.nats.url"))
+		if err != nil {
+			log.Fatalf("can't connect to nats: %s", err)
+		}
+		defer nc.Close()
+		st, err = streamer.NewNatsStreamer(nc)
+		if err != nil {
+			log.Fatalf("error initializing nats streamer: %s", err)
+		}
+	default:
+		st, err = streamer.NewRedisStreamer(rdb)
+		if err != nil {
+			log.Fatalf("error initializing redis streamer: %s", err)
+		}
 	}
 
-	app := usecase.NewApp(db, ft, streamer)
+	app := usecase.NewApp(db, ft, st, viper.GetInt("streamer.concurrency"))
 	if err != nil {
 		log.Fatalf("cannot initalize application: %s", err)
 	}
+	admin := &auth.Credentials{
+		Username: viper.GetString("admin.username"),
+		Password: viper.GetString("admin.password"),
+		Token:    viper.GetString("admin.token"),
+	}
+	if admin.Empty() {
+		log.Warnf("missing admin credentials, admin requests will be rejected")
+	}
 	service := api.NewService(app)
 
 	server := gogrpc.NewFinderServer(app)
@@ -101,13 +129,19 @@ func main() {
 	c := cors.New(config)
 
 	r := gin.Default()
-	r.Use(c)
+	r.Use(c, httperr.Handler())
 	g := r.Group("/finder")
 	{
 		g.POST("/search-recipes", service.SearchRecipes)
 		g.POST("/search-by-tag", service.SearchByTag)
 		g.POST("/all-recipe-tags", service.AllRecipeTags)
 	}
+	x := g.Group("/x", auth.Required(admin))
+	{
+		x.GET("/streams", service.StreamsInfo)
+		x.POST("/streams/:stream/rewind", service.Rewind)
+		x.POST("/reindex", service.RebuildIndex)
+	}
 	go r.Run()
 
 	// wait for shutdown
@@ -14,10 +14,6 @@ func NewRedisDB(client *redis.Client) *redisDB {
 	return &redisDB{client}
 }
 
-func (r *redisDB) IDExists(id string) (bool, error) {
-	return false, nil
-}
-
 func (r *redisDB) Tags(index, field string) ([]string, error) {
 	cmd := redis.NewStringSliceCmd("ft.tagvals", index, field)
 	err := r.rdb.Process(cmd)
@@ -1,5 +1,10 @@
 package grpc
 
+import (
+	"gospiga/pkg/streamer"
+)
+
 type App interface {
 	AllRecipeTags() ([]string, error)
+	StreamsInfo([]string) ([]*streamer.StreamInfo, error)
 }
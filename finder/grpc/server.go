@@ -5,6 +5,7 @@ package grpc
 import (
 	"context"
 	"fmt"
+	"time"
 
 	pb "gospiga/proto"
 )
@@ -25,3 +26,26 @@ func (s *finderServer) AllRecipeTags(ctx context.Context, req *pb.AllRecipeTagsR
 
 	return &pb.AllRecipeTagsResponse{Tags: tags}, nil
 }
+
+func (s *finderServer) StreamsInfo(ctx context.Context, req *pb.StreamsInfoRequest) (*pb.StreamsInfoResponse, error) {
+	infos, err := s.app.StreamsInfo(req.Streams)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving streams info: %w", err)
+	}
+
+	res := &pb.StreamsInfoResponse{}
+	for _, info := range infos {
+		si := &pb.StreamInfo{Stream: info.Stream, Length: info.Length}
+		for _, g := range info.Groups {
+			si.Groups = append(si.Groups, &pb.GroupInfo{
+				Name:                g.Name,
+				LastDeliveredId:     g.LastDeliveredID,
+				Pending:             g.Pending,
+				OldestPendingIdleMs: int64(g.OldestPendingIdle / time.Millisecond),
+				Consumers:           g.Consumers,
+			})
+		}
+		res.Streams = append(res.Streams, si)
+	}
+	return res, nil
+}
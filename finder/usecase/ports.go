@@ -1,13 +1,14 @@
 package usecase
 
 import (
+	"context"
+
 	"gospiga/finder/domain"
 	"gospiga/finder/fulltext"
 	"gospiga/pkg/streamer"
 )
 
 type DB interface {
-	IDExists(id string) (bool, error)
 	Tags(index, field string) ([]string, error)
 }
 
@@ -21,5 +22,8 @@ type FT interface {
 type Streamer interface {
 	Ack(stream, group string, ids ...string) error
 	Add(string, *streamer.Message) error
-	ReadGroup(*streamer.StreamArgs) error
+	ReadGroup(context.Context, *streamer.StreamArgs) error
+	Info(streams ...string) ([]*streamer.StreamInfo, error)
+	SetGroupID(stream, group, id string) error
+	Replay(ctx context.Context, stream, start, end string, h streamer.Handler) (*streamer.ReplayStats, error)
 }
@@ -1,15 +1,15 @@
 package usecase
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"sync"
 
 	"gospiga/finder/domain"
 	"gospiga/finder/fulltext"
+	"gospiga/pkg/errors"
+	"gospiga/pkg/events"
 	"gospiga/pkg/log"
 	"gospiga/pkg/streamer"
-	"gospiga/pkg/types"
 )
 
 func (a *app) SearchRecipes(query string) ([]*fulltext.Recipe, error) {
@@ -24,126 +24,49 @@ func (a *app) AllRecipeTags() ([]string, error) {
 	return a.db.Tags("recipes", "tags")
 }
 
-func (a *app) readNewRecipes() {
-	msgChan := make(chan streamer.Message)
-	var wg sync.WaitGroup
-
-	streams := []string{
-		savedRecipeStream,
-		deletedRecipeStream,
-	}
-	args := &streamer.StreamArgs{
-		Streams:  streams,
+func (a *app) readNewRecipes(ctx context.Context, concurrency int) error {
+	a.consumer = &streamer.StreamArgs{
 		Group:    group,
-		Consumer: "finder-usecase",
-		Messages: msgChan,
-		Exit:     a.shutdown,
-		WG:       &wg,
-	}
-	err := a.streamer.ReadGroup(args)
-	if err != nil {
-		log.Fatal(err)
+		Consumer: streamer.ConsumerName(group),
+		// replicas share the work
+		Mode: streamer.WorkSharing,
+		Handlers: map[string]streamer.Handler{
+			savedRecipeStream:   events.OnRecipeSaved(a.indexRecipe),
+			removedRecipeStream: events.OnRecipeDeleted(a.deleteRecipe),
+		},
+		Retries:     retryPolicies,
+		MaxAttempts: maxAttempts,
+		ClaimIdle:   claimIdle,
+		Heartbeat:   heartbeat,
+		Concurrency: concurrency,
+		// keep the events of the same recipe in order
+		Key: events.Key,
+		// skip the events already handled
+		Inbox: &streamer.Inbox{TTL: inboxTTL, EventID: events.ID},
 	}
 
-	for {
-		select {
-		case msg := <-msgChan:
-			switch msg.Stream {
-			case savedRecipeStream:
-				// ping-pong to parse recipe from message
-				var recipe types.Recipe
-				jr, err := json.Marshal(msg.Payload)
-				if err != nil {
-					log.Errorf("cannot read recipe ID from message ID %q", msg.ID)
-					a.discardMessage(&msg, &wg)
-					continue
-				}
-				err = json.Unmarshal(jr, &recipe)
-				if err != nil {
-					log.Errorf("cannot parse recipe ID from message ID %q", msg.ID)
-					a.discardMessage(&msg, &wg)
-					continue
-				}
-				log.Debugf("Got message for a saved recipe ID %q", recipe.ExternalID)
-
-				a.indexRecipe(recipe, msg.Stream, msg.ID, &wg)
-
-			case deletedRecipeStream:
-				recipeID, ok := msg.Payload.(string)
-				if !ok {
-					log.Errorf("cannot read recipe ID from message ID %q", msg.ID)
-					a.discardMessage(&msg, &wg)
-					continue
-				}
-				log.Debugf("Got message for deleted recipe ID %q", recipeID)
-
-				a.deleteRecipe(recipeID, msg.ID, &wg)
-			}
-
-		case <-a.shutdown:
-			// time to exit
-			return
-		}
-	}
+	return a.streamer.ReadGroup(ctx, a.consumer)
 }
 
-func (a *app) indexRecipe(recipe types.Recipe, stream, messageID string, wg *sync.WaitGroup) {
-	// unleash streamer
-	defer wg.Done()
-
-	// check if ID is already indexed
-	if exists, _ := a.db.IDExists(fmt.Sprintf("recipe:%s", recipe.ID)); exists {
-		log.Debugf("recipe ID %q already indexed", recipe.ID)
-
-		err := a.streamer.Ack(stream, group, messageID)
-		if err != nil {
-			log.Errorf("error ack'ing msg ID %q", messageID)
-			return
-		}
+func (a *app) indexRecipe(ctx context.Context, msg streamer.Message, e *events.RecipeSaved) error {
+	if e.Recipe == nil {
+		return errors.Permanent(fmt.Errorf("missing recipe in message ID %q", msg.ID))
 	}
+	log.Debugf("Got message for a saved recipe ID %q", e.Recipe.ExternalID)
 
-	r := domain.FromType(&recipe)
+	r := domain.FromType(e.Recipe)
 
 	// index recipe
-	err := a.ft.IndexRecipe(r)
-	if err != nil {
-		log.Error(err)
-		// TODO: ack??
-		return
-	}
-
-	// ack (& add recipeIndexed?)
-	err = a.streamer.Ack(stream, group, messageID)
-	if err != nil {
-		log.Errorf("error ack'ing msg ID %q", messageID)
-	}
+	return a.ft.IndexRecipe(r)
 }
 
-func (a *app) deleteRecipe(recipeID, messageID string, wg *sync.WaitGroup) {
-	// unleash streamer
-	defer wg.Done()
+func (a *app) deleteRecipe(ctx context.Context, msg streamer.Message, e *events.RecipeDeleted) error {
+	log.Debugf("Got message for deleted recipe ID %q", e.RecipeID)
 
-	err := a.ft.DeleteRecipe(recipeID)
+	err := a.ft.DeleteRecipe(e.RecipeID)
 	if err != nil {
+		// a missing document is not worth retrying
 		log.Errorf("error deleting recipe from index: %s", err)
-
-		err := a.streamer.Ack(deletedRecipeStream, group, messageID)
-		if err != nil {
-			log.Errorf("error ack'ing msg ID %q", messageID)
-		}
-		return
-	}
-
-	err = a.streamer.Ack(deletedRecipeStream, group, messageID)
-	if err != nil {
-		log.Errorf("error ack'ing msg ID %q", messageID)
-	}
-}
-
-func (a *app) discardMessage(m *streamer.Message, wg *sync.WaitGroup) {
-	defer wg.Done()
-	err := a.streamer.Ack(m.Stream, group, m.ID)
-	if err != nil {
-		log.Warnf("error acknowledging message: %s", err)
 	}
+	return nil
 }
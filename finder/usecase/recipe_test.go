@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gospiga/finder/domain"
+	"gospiga/pkg/events"
+	"gospiga/pkg/streamer"
+	"gospiga/pkg/types"
+)
+
+type fakeDB struct {
+	DB
+}
+
+type fakeFT struct {
+	FT
+	mu      sync.Mutex
+	indexed map[string]*domain.Recipe
+}
+
+func (ft *fakeFT) IndexRecipe(r *domain.Recipe) error {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	ft.indexed[r.ExternalID] = r
+	return nil
+}
+
+func (ft *fakeFT) DeleteRecipe(id string) error {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	delete(ft.indexed, id)
+	return nil
+}
+
+func (ft *fakeFT) count() int {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	return len(ft.indexed)
+}
+
+func TestReadNewRecipes(t *testing.T) {
+	tests := []struct {
+		name     string
+		stream   string
+		event    events.Event
+		indexed  map[string]*domain.Recipe
+		expected int
+	}{
+		{
+			name:     "saved recipe indexed",
+			stream:   savedRecipeStream,
+			event:    events.NewRecipeSaved("test", &types.Recipe{ID: "0x1", ExternalID: "r1", Title: "title", MainImage: &types.Image{}}),
+			indexed:  map[string]*domain.Recipe{},
+			expected: 1,
+		},
+		{
+			name:     "deleted recipe removed",
+			stream:   removedRecipeStream,
+			event:    events.NewRecipeDeleted("test", "r0"),
+			indexed:  map[string]*domain.Recipe{"r0": {}},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := streamer.NewMemoryStreamer()
+			ft := &fakeFT{indexed: tt.indexed}
+
+			a := NewApp(&fakeDB{}, ft, s, 1)
+			defer a.CloseGracefully()
+
+			msg, err := events.Encode(tt.event, events.JSON)
+			require.NoError(t, err)
+			require.NoError(t, s.Add(tt.stream, msg))
+			require.Eventually(t, func() bool {
+				return ft.count() == tt.expected
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestRebuildIndex(t *testing.T) {
+	require := require.New(t)
+
+	s := streamer.NewMemoryStreamer()
+	ft := &fakeFT{indexed: map[string]*domain.Recipe{}}
+	a := NewApp(&fakeDB{}, ft, s, 1)
+	defer a.CloseGracefully()
+
+	recipe := func(id string) *types.Recipe {
+		return &types.Recipe{ID: "0x" + id, ExternalID: id, MainImage: &types.Image{}}
+	}
+	history := []struct {
+		stream string
+		event  events.Event
+	}{
+		{savedRecipeStream, events.NewRecipeSaved("test", recipe("r1"))},
+		{savedRecipeStream, events.NewRecipeSaved("test", recipe("r2"))},
+		{removedRecipeStream, events.NewRecipeDeleted("test", "r1")},
+		{removedRecipeStream, events.NewRecipeDeleted("test", "r3")},
+		{savedRecipeStream, events.NewRecipeSaved("test", recipe("r3"))},
+	}
+	for _, h := range history {
+		msg, err := events.Encode(h.event, events.JSON)
+		require.NoError(err)
+		require.NoError(s.Add(h.stream, msg))
+	}
+	require.Eventually(func() bool {
+		return a.consumer.Stats().Handled == int64(len(history))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// lose the index
+	ft.mu.Lock()
+	ft.indexed = map[string]*domain.Recipe{}
+	ft.mu.Unlock()
+
+	stats, err := a.RebuildIndex(context.Background())
+	require.NoError(err)
+	require.Equal(int64(3), stats[savedRecipeStream].Replayed)
+	require.Equal(int64(2), stats[removedRecipeStream].Replayed)
+
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	require.Len(ft.indexed, 2)
+	require.Contains(ft.indexed, "r2")
+	require.Contains(ft.indexed, "r3")
+}
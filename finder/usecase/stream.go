@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+
+	"gospiga/pkg/events"
+	"gospiga/pkg/log"
+	"gospiga/pkg/streamer"
+)
+
+// StreamsInfo reports the state of the given streams and of their consumer
+// groups, the streams read by the finder by default.
+func (a *app) StreamsInfo(streams []string) ([]*streamer.StreamInfo, error) {
+	if len(streams) == 0 {
+		streams = a.consumer.Streams()
+	}
+	return a.streamer.Info(streams...)
+}
+
+// SetGroupID moves a consumer group on the stream to the given ID, the finder
+// group by default, so that the entries after it are delivered again.
+func (a *app) SetGroupID(stream, group, id string) error {
+	if group == "" {
+		group = a.consumer.Group
+	}
+	return a.streamer.SetGroupID(stream, group, id)
+}
+
+// RebuildIndex indexes again the recipes from the history of the saved
+// recipes stream, then removes from the index the ones deleted afterwards.
+func (a *app) RebuildIndex(ctx context.Context) (map[string]*streamer.ReplayStats, error) {
+	// ID of the last save of each recipe
+	saved := make(map[string]string)
+	index := events.OnRecipeSaved(func(ctx context.Context, msg streamer.Message, e *events.RecipeSaved) error {
+		saved[e.Key()] = msg.ID
+		return a.indexRecipe(ctx, msg, e)
+	})
+	remove := events.OnRecipeDeleted(func(ctx context.Context, msg streamer.Message, e *events.RecipeDeleted) error {
+		if id, ok := saved[e.Key()]; ok && streamer.CompareIDs(msg.ID, id) < 0 {
+			// saved again after being deleted
+			return nil
+		}
+		return a.deleteRecipe(ctx, msg, e)
+	})
+
+	stats := make(map[string]*streamer.ReplayStats)
+	var err error
+	stats[savedRecipeStream], err = a.streamer.Replay(ctx, savedRecipeStream, "-", "+", index)
+	if err != nil {
+		return stats, err
+	}
+	stats[removedRecipeStream], err = a.streamer.Replay(ctx, removedRecipeStream, "-", "+", remove)
+	if err != nil {
+		return stats, err
+	}
+
+	log.Infof("index rebuilt from %d saved and %d deleted recipe(s)",
+		stats[savedRecipeStream].Replayed, stats[removedRecipeStream].Replayed)
+	return stats, nil
+}
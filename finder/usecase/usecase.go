@@ -1,33 +1,65 @@
 package usecase
 
+import (
+	"context"
+	"time"
+
+	"gospiga/pkg/events"
+	"gospiga/pkg/log"
+	"gospiga/pkg/streamer"
+)
+
 const (
-	savedRecipeStream   = "saved-recipes"
-	deletedRecipeStream = "deleted-recipes"
+	savedRecipeStream   = events.SavedRecipes
+	removedRecipeStream = events.RemovedRecipes
 	group               = "finder-usecase"
+	maxAttempts         = 5
+	claimIdle           = 5 * time.Minute
+	heartbeat           = 10 * time.Second
+	inboxTTL            = time.Hour
 )
 
+var retryPolicies = map[string]*streamer.RetryPolicy{
+	savedRecipeStream: {
+		MaxAttempts: maxAttempts,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+	},
+}
+
 type app struct {
 	db       DB
 	ft       FT
 	streamer Streamer
-	shutdown chan struct{}
+	shutdown context.CancelFunc
+	consumer *streamer.StreamArgs
 }
 
 // CloseGracefully sends the shutdown signal to start closing all app processes
 func (a *app) CloseGracefully() {
-	close(a.shutdown)
+	a.shutdown()
+
+	stats := a.consumer.Stats()
+	log.Infof("handled %d message(s), %d failed, %.2f msg/s", stats.Handled, stats.Failed, stats.Throughput())
 }
 
-func NewApp(db DB, ft FT, streamer Streamer) *app {
+// NewApp returns the app, handling up to concurrency stream messages at the
+// same time.
+func NewApp(db DB, ft FT, st Streamer, concurrency int) *app {
+	ctx, cancel := context.WithCancel(context.Background())
 	a := &app{
 		db:       db,
 		ft:       ft,
-		streamer: streamer,
-		shutdown: make(chan struct{}),
+		streamer: st,
+		shutdown: cancel,
 	}
 
 	// start streamer to listen for new recipes.
-	go a.readNewRecipes()
+	err := a.readNewRecipes(ctx, concurrency)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	return a
 }
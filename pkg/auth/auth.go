@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	errs "gospiga/pkg/errors"
+	"gospiga/pkg/httperr"
+)
+
+// Credentials of the admin API, sent either via HTTP basic auth or as a
+// bearer token. Requests are rejected if none are set.
+type Credentials struct {
+	Username string
+	Password string
+	Token    string
+}
+
+// Empty reports whether no credentials are set.
+func (c *Credentials) Empty() bool {
+	return c.Username == "" && c.Token == ""
+}
+
+// Authorized reports whether the request carries the credentials.
+func (c *Credentials) Authorized(r *http.Request) bool {
+	if c.Token != "" {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(c.Token)) == 1 {
+			return true
+		}
+	}
+	if c.Username != "" {
+		user, pass, ok := r.BasicAuth()
+		if ok &&
+			subtle.ConstantTimeCompare([]byte(user), []byte(c.Username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(pass), []byte(c.Password)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Required aborts the requests without the credentials.
+func Required(c *Credentials) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !c.Authorized(ctx.Request) {
+			httperr.Abort(ctx, errs.Unauthorized(errors.New("invalid admin credentials")))
+			return
+		}
+		ctx.Next()
+	}
+}
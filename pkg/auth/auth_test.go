@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorized(t *testing.T) {
+	tests := []struct {
+		name  string
+		creds Credentials
+		set   func(r *http.Request)
+		want  bool
+	}{
+		{
+			name:  "no credentials set",
+			creds: Credentials{},
+			set:   func(r *http.Request) { r.SetBasicAuth("", "") },
+		},
+		{
+			name:  "token",
+			creds: Credentials{Token: "s3cret"},
+			set:   func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cret") },
+			want:  true,
+		},
+		{
+			name:  "wrong token",
+			creds: Credentials{Token: "s3cret"},
+			set:   func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") },
+		},
+		{
+			name:  "basic auth",
+			creds: Credentials{Username: "admin", Password: "pass", Token: "s3cret"},
+			set:   func(r *http.Request) { r.SetBasicAuth("admin", "pass") },
+			want:  true,
+		},
+		{
+			name:  "wrong password",
+			creds: Credentials{Username: "admin", Password: "pass"},
+			set:   func(r *http.Request) { r.SetBasicAuth("admin", "") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := http.NewRequest(http.MethodPost, "/x", nil)
+			require.NoError(t, err)
+			tt.set(r)
+			require.Equal(t, tt.want, tt.creds.Authorized(r))
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package errors
+
+import (
+	"errors"
+)
+
+// Kind classifies an error for the callers, e.g. to pick a status code.
+type Kind string
+
+const (
+	KindInternal     Kind = "internal"
+	KindNotFound     Kind = "not_found"
+	KindValidation   Kind = "validation"
+	KindConflict     Kind = "conflict"
+	KindUnavailable  Kind = "unavailable"
+	KindUnauthorized Kind = "unauthorized"
+)
+
+// ErrKind marks an error with its kind.
+type ErrKind struct {
+	Kind Kind
+	Err  error
+}
+
+func (e ErrKind) Error() string {
+	return e.Err.Error()
+}
+
+func (e ErrKind) Unwrap() error {
+	return e.Err
+}
+
+func withKind(k Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return ErrKind{Kind: k, Err: err}
+}
+
+// NotFound wraps err marking the thing looked for as missing.
+func NotFound(err error) error {
+	return withKind(KindNotFound, err)
+}
+
+// Validation wraps err marking the input as invalid.
+func Validation(err error) error {
+	return withKind(KindValidation, err)
+}
+
+// Conflict wraps err marking it as a clash with the current state.
+func Conflict(err error) error {
+	return withKind(KindConflict, err)
+}
+
+// Unavailable wraps err marking a dependency as unavailable for now.
+func Unavailable(err error) error {
+	return withKind(KindUnavailable, err)
+}
+
+// Unauthorized wraps err marking the caller as not authorized.
+func Unauthorized(err error) error {
+	return withKind(KindUnauthorized, err)
+}
+
+// KindOf returns the kind of the first error in err's chain having one,
+// KindInternal if none.
+func KindOf(err error) Kind {
+	var errk ErrKind
+	if errors.As(err, &errk) {
+		return errk.Kind
+	}
+	var errdup ErrDuplicateID
+	if errors.As(err, &errdup) {
+		return KindConflict
+	}
+	return KindInternal
+}
@@ -0,0 +1,32 @@
+package errors
+
+import (
+	"errors"
+)
+
+// ErrPermanent marks an error that won't go away by trying again.
+type ErrPermanent struct {
+	Err error
+}
+
+func (e ErrPermanent) Error() string {
+	return e.Err.Error()
+}
+
+func (e ErrPermanent) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps err marking it as permanent.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return ErrPermanent{Err: err}
+}
+
+// IsPermanent reports whether any error in err's chain is permanent.
+func IsPermanent(err error) bool {
+	var errp ErrPermanent
+	return errors.As(err, &errp)
+}
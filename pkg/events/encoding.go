@@ -0,0 +1,238 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+
+	"gospiga/pkg/errors"
+	"gospiga/pkg/streamer"
+)
+
+// Encoding of the event data.
+type Encoding string
+
+const (
+	JSON     Encoding = "json"
+	Protobuf Encoding = "protobuf"
+)
+
+// Envelope is the payload of the stream messages carrying an event.
+type Envelope struct {
+	Type     string   `json:"type"`
+	Encoding Encoding `json:"encoding"`
+	// Data is the encoded event. Protobuf data is a base64 JSON string.
+	Data json.RawMessage `json:"data"`
+}
+
+// Encode returns the message carrying the given event, encoded with enc.
+func Encode(e Event, enc Encoding) (*streamer.Message, error) {
+	var data []byte
+	var err error
+	switch enc {
+	case JSON:
+		data, err = json.Marshal(e)
+	case Protobuf:
+		var m proto.Message
+		m, err = toProto(e)
+		if err != nil {
+			return nil, err
+		}
+		var b []byte
+		b, err = proto.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		data, err = json.Marshal(b)
+	default:
+		err = fmt.Errorf("unknown encoding %q", enc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamer.Message{
+		Payload: &Envelope{Type: e.Type(), Encoding: enc, Data: data},
+	}, nil
+}
+
+// Decode returns the event carried by the message, whatever its type.
+func Decode(msg streamer.Message) (Event, error) {
+	env, err := envelope(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var e Event
+	switch env.Type {
+	case TypeRecipeCreated:
+		e = &RecipeCreated{}
+	case TypeRecipeUpdated:
+		e = &RecipeUpdated{}
+	case TypeRecipeDeleted:
+		e = &RecipeDeleted{}
+	case TypeRecipeSaved:
+		e = &RecipeSaved{}
+	default:
+		return nil, errors.Permanent(fmt.Errorf("unknown event type %q in message ID %q", env.Type, msg.ID))
+	}
+	return e, decodeEnvelope(msg, env, e)
+}
+
+// DecodeRecipeCreated returns the RecipeCreated event carried by the message.
+func DecodeRecipeCreated(msg streamer.Message) (*RecipeCreated, error) {
+	e := &RecipeCreated{}
+	return e, decode(msg, e)
+}
+
+// DecodeRecipeUpdated returns the RecipeUpdated event carried by the message.
+func DecodeRecipeUpdated(msg streamer.Message) (*RecipeUpdated, error) {
+	e := &RecipeUpdated{}
+	return e, decode(msg, e)
+}
+
+// DecodeRecipeDeleted returns the RecipeDeleted event carried by the message.
+func DecodeRecipeDeleted(msg streamer.Message) (*RecipeDeleted, error) {
+	e := &RecipeDeleted{}
+	return e, decode(msg, e)
+}
+
+// DecodeRecipeSaved returns the RecipeSaved event carried by the message.
+func DecodeRecipeSaved(msg streamer.Message) (*RecipeSaved, error) {
+	e := &RecipeSaved{}
+	return e, decode(msg, e)
+}
+
+// OnRecipeCreated returns a stream handler decoding RecipeCreated events. The
+// message is passed along to the handler, e.g. to acknowledge it.
+func OnRecipeCreated(h func(context.Context, streamer.Message, *RecipeCreated) error) streamer.Handler {
+	return func(ctx context.Context, msg streamer.Message) error {
+		e, err := DecodeRecipeCreated(msg)
+		if err != nil {
+			return err
+		}
+		return h(ctx, msg, e)
+	}
+}
+
+// OnRecipeUpdated returns a stream handler decoding RecipeUpdated events.
+func OnRecipeUpdated(h func(context.Context, streamer.Message, *RecipeUpdated) error) streamer.Handler {
+	return func(ctx context.Context, msg streamer.Message) error {
+		e, err := DecodeRecipeUpdated(msg)
+		if err != nil {
+			return err
+		}
+		return h(ctx, msg, e)
+	}
+}
+
+// OnRecipeDeleted returns a stream handler decoding RecipeDeleted events.
+func OnRecipeDeleted(h func(context.Context, streamer.Message, *RecipeDeleted) error) streamer.Handler {
+	return func(ctx context.Context, msg streamer.Message) error {
+		e, err := DecodeRecipeDeleted(msg)
+		if err != nil {
+			return err
+		}
+		return h(ctx, msg, e)
+	}
+}
+
+// OnRecipeSaved returns a stream handler decoding RecipeSaved events.
+func OnRecipeSaved(h func(context.Context, streamer.Message, *RecipeSaved) error) streamer.Handler {
+	return func(ctx context.Context, msg streamer.Message) error {
+		e, err := DecodeRecipeSaved(msg)
+		if err != nil {
+			return err
+		}
+		return h(ctx, msg, e)
+	}
+}
+
+// Key returns the ordering key of the event carried by the message, to be
+// used as streamer.StreamArgs Key.
+func Key(msg streamer.Message) string {
+	e, err := Decode(msg)
+	if err != nil {
+		return msg.ID
+	}
+	return e.Key()
+}
+
+// decode decodes the message into e, making sure it carries an event of the
+// same type.
+func decode(msg streamer.Message, e Event) error {
+	env, err := envelope(msg)
+	if err != nil {
+		return err
+	}
+	if env.Type != e.Type() {
+		return errors.Permanent(fmt.Errorf("message ID %q carries a %s event, expected %s", msg.ID, env.Type, e.Type()))
+	}
+	return decodeEnvelope(msg, env, e)
+}
+
+func decodeEnvelope(msg streamer.Message, env *Envelope, e Event) error {
+	var err error
+	switch env.Encoding {
+	case JSON:
+		err = json.Unmarshal(env.Data, e)
+	case Protobuf:
+		var b []byte
+		err = json.Unmarshal(env.Data, &b)
+		if err != nil {
+			break
+		}
+		var m proto.Message
+		m, err = newProto(e)
+		if err != nil {
+			break
+		}
+		err = proto.Unmarshal(b, m)
+		if err != nil {
+			break
+		}
+		err = fromProto(m, e)
+	default:
+		err = fmt.Errorf("unknown encoding %q", env.Encoding)
+	}
+	if err != nil {
+		return errors.Permanent(fmt.Errorf("cannot decode message ID %q: %w", msg.ID, err))
+	}
+
+	if v := e.Metadata().Version; v < 1 || v > SchemaVersion {
+		return errors.Permanent(fmt.Errorf("unsupported schema version %d in message ID %q", v, msg.ID))
+	}
+	return nil
+}
+
+// envelope reads the envelope from the message payload.
+func envelope(msg streamer.Message) (*Envelope, error) {
+	raw := msg.RawPayload
+	if raw == nil {
+		// message built in process
+		var err error
+		raw, err = json.Marshal(msg.Payload)
+		if err != nil {
+			return nil, errors.Permanent(err)
+		}
+	}
+
+	var env Envelope
+	err := json.Unmarshal(raw, &env)
+	if err != nil || env.Type == "" {
+		return nil, errors.Permanent(fmt.Errorf("message ID %q does not carry an event", msg.ID))
+	}
+	return &env, nil
+}
+
+// ID returns the ID of the event carried by the message, to be used as
+// streamer.Inbox EventID. Messages not carrying an event have no ID.
+func ID(msg streamer.Message) string {
+	e, err := Decode(msg)
+	if err != nil {
+		return ""
+	}
+	return e.Metadata().ID
+}
@@ -0,0 +1,148 @@
+//go:generate protoc -I ../../proto --go_out=../../proto ../../proto/events.proto
+
+// Package events defines the events exchanged by the services over the
+// streams, along with their encodings.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"gospiga/pkg/types"
+)
+
+// SchemaVersion is the version of the events produced by this package.
+// Decoders accept any version up to it.
+const SchemaVersion = 1
+
+// Streams carrying the events.
+const (
+	NewRecipes     = "new-recipes"
+	UpdatedRecipes = "updated-recipes"
+	DeletedRecipes = "deleted-recipes"
+	SavedRecipes   = "saved-recipes"
+	RemovedRecipes = "removed-recipes"
+	// BulkRecipes carries the recipes created by bulk loads, at a lower
+	// priority than NewRecipes.
+	BulkRecipes = "bulk-recipes"
+)
+
+// Event types.
+const (
+	TypeRecipeCreated = "RecipeCreated"
+	TypeRecipeUpdated = "RecipeUpdated"
+	TypeRecipeDeleted = "RecipeDeleted"
+	TypeRecipeSaved   = "RecipeSaved"
+)
+
+// StreamTypes maps each stream to the type of the events it carries.
+var StreamTypes = map[string]string{
+	NewRecipes:     TypeRecipeCreated,
+	UpdatedRecipes: TypeRecipeUpdated,
+	DeletedRecipes: TypeRecipeDeleted,
+	SavedRecipes:   TypeRecipeSaved,
+	RemovedRecipes: TypeRecipeDeleted,
+	BulkRecipes:    TypeRecipeCreated,
+}
+
+// Event is implemented by all the events.
+type Event interface {
+	// Type of the event.
+	Type() string
+	// Key is the ID of the recipe the event is about, events with the same
+	// key must be processed in order.
+	Key() string
+	// Metadata of the event.
+	Metadata() Meta
+}
+
+// Meta holds the metadata common to all the events.
+type Meta struct {
+	Version   int       `json:"version"`
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"`
+}
+
+// NewMeta returns the metadata of a new event produced by source.
+func NewMeta(source string) Meta {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return Meta{
+		Version:   SchemaVersion,
+		ID:        hex.EncodeToString(id),
+		Timestamp: time.Now().UTC(),
+		Source:    source,
+	}
+}
+
+// Metadata of the event.
+func (m Meta) Metadata() Meta {
+	return m
+}
+
+// RecipeCreated is sent when a recipe is published on the provider.
+type RecipeCreated struct {
+	Meta
+	RecipeID string `json:"recipeId"`
+	// JobID is the ID of the job loading the recipe, if any.
+	JobID string `json:"jobId,omitempty"`
+}
+
+// NewRecipeCreated returns a RecipeCreated event for the given recipe ID.
+func NewRecipeCreated(source, recipeID string) *RecipeCreated {
+	return &RecipeCreated{Meta: NewMeta(source), RecipeID: recipeID}
+}
+
+func (e *RecipeCreated) Type() string { return TypeRecipeCreated }
+func (e *RecipeCreated) Key() string  { return e.RecipeID }
+
+// RecipeUpdated is sent when a recipe is updated on the provider.
+type RecipeUpdated struct {
+	Meta
+	RecipeID string `json:"recipeId"`
+}
+
+// NewRecipeUpdated returns a RecipeUpdated event for the given recipe ID.
+func NewRecipeUpdated(source, recipeID string) *RecipeUpdated {
+	return &RecipeUpdated{Meta: NewMeta(source), RecipeID: recipeID}
+}
+
+func (e *RecipeUpdated) Type() string { return TypeRecipeUpdated }
+func (e *RecipeUpdated) Key() string  { return e.RecipeID }
+
+// RecipeDeleted is sent when a recipe is removed from the provider.
+type RecipeDeleted struct {
+	Meta
+	RecipeID string `json:"recipeId"`
+}
+
+// NewRecipeDeleted returns a RecipeDeleted event for the given recipe ID.
+func NewRecipeDeleted(source, recipeID string) *RecipeDeleted {
+	return &RecipeDeleted{Meta: NewMeta(source), RecipeID: recipeID}
+}
+
+func (e *RecipeDeleted) Type() string { return TypeRecipeDeleted }
+func (e *RecipeDeleted) Key() string  { return e.RecipeID }
+
+// RecipeSaved is sent when a recipe has been stored, carrying the full
+// recipe.
+type RecipeSaved struct {
+	Meta
+	Recipe *types.Recipe `json:"recipe"`
+}
+
+// NewRecipeSaved returns a RecipeSaved event for the given recipe.
+func NewRecipeSaved(source string, recipe *types.Recipe) *RecipeSaved {
+	return &RecipeSaved{Meta: NewMeta(source), Recipe: recipe}
+}
+
+func (e *RecipeSaved) Type() string { return TypeRecipeSaved }
+
+func (e *RecipeSaved) Key() string {
+	if e.Recipe == nil {
+		return ""
+	}
+	return e.Recipe.ExternalID
+}
@@ -0,0 +1,214 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gospiga/pkg/errors"
+	"gospiga/pkg/streamer"
+	"gospiga/pkg/types"
+)
+
+func testRecipe() *types.Recipe {
+	return &types.Recipe{
+		ID:         "0x2a",
+		ExternalID: "12345",
+		Title:      "Tiramisù",
+		MainImage:  &types.Image{URL: "https://example.com/tiramisu.jpg"},
+		Difficulty: types.DifficultyMid,
+		Servings:   6,
+		Ingredients: []*types.Ingredient{
+			{Name: "mascarpone", Quantity: "500", UnitOfMeasure: "g"},
+			{Name: "cacao"},
+		},
+		Steps: []*types.Step{
+			{Heading: "Crema", Body: "Montare..", Image: &types.Image{URL: "https://example.com/step.jpg"}},
+		},
+		Slug: "tiramisu",
+	}
+}
+
+// TestContract sends each event over its stream as a producer would and
+// makes sure that the typed handler of the stream gets it back.
+func TestContract(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  string
+		event   Event
+		handler func(chan<- Event) streamer.Handler
+	}{
+		{
+			name:   "recipe created",
+			stream: NewRecipes,
+			event:  NewRecipeCreated("server", "12345"),
+			handler: func(ch chan<- Event) streamer.Handler {
+				return OnRecipeCreated(func(ctx context.Context, msg streamer.Message, e *RecipeCreated) error {
+					ch <- e
+					return nil
+				})
+			},
+		},
+		{
+			name:   "recipe loaded by a job",
+			stream: BulkRecipes,
+			event: &RecipeCreated{
+				Meta:     NewMeta("server"),
+				RecipeID: "12345",
+				JobID:    "job1",
+			},
+			handler: func(ch chan<- Event) streamer.Handler {
+				return OnRecipeCreated(func(ctx context.Context, msg streamer.Message, e *RecipeCreated) error {
+					ch <- e
+					return nil
+				})
+			},
+		},
+		{
+			name:   "recipe updated",
+			stream: UpdatedRecipes,
+			event:  NewRecipeUpdated("server", "12345"),
+			handler: func(ch chan<- Event) streamer.Handler {
+				return OnRecipeUpdated(func(ctx context.Context, msg streamer.Message, e *RecipeUpdated) error {
+					ch <- e
+					return nil
+				})
+			},
+		},
+		{
+			name:   "recipe deleted",
+			stream: DeletedRecipes,
+			event:  NewRecipeDeleted("server", "12345"),
+			handler: func(ch chan<- Event) streamer.Handler {
+				return OnRecipeDeleted(func(ctx context.Context, msg streamer.Message, e *RecipeDeleted) error {
+					ch <- e
+					return nil
+				})
+			},
+		},
+		{
+			name:   "recipe saved",
+			stream: SavedRecipes,
+			event:  NewRecipeSaved("server", testRecipe()),
+			handler: func(ch chan<- Event) streamer.Handler {
+				return OnRecipeSaved(func(ctx context.Context, msg streamer.Message, e *RecipeSaved) error {
+					ch <- e
+					return nil
+				})
+			},
+		},
+	}
+
+	for _, enc := range []Encoding{JSON, Protobuf} {
+		for _, tt := range tests {
+			t.Run(string(enc)+"/"+tt.name, func(t *testing.T) {
+				require := require.New(t)
+				require.Equal(StreamTypes[tt.stream], tt.event.Type())
+
+				s := streamer.NewMemoryStreamer()
+				msg, err := Encode(tt.event, enc)
+				require.NoError(err)
+				require.NoError(s.Add(tt.stream, msg))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Event, 1)
+				args := &streamer.StreamArgs{
+					Group:    "test",
+					Consumer: "test",
+					Handlers: map[string]streamer.Handler{tt.stream: tt.handler(ch)},
+				}
+				require.NoError(s.ReadGroup(ctx, args))
+
+				select {
+				case got := <-ch:
+					require.Equal(tt.event, got)
+					require.Equal(tt.event.Key(), got.Key())
+				case <-time.After(5 * time.Second):
+					t.Fatal("timeout waiting for event")
+				}
+			})
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	saved, err := Encode(NewRecipeSaved("server", testRecipe()), JSON)
+	require.NoError(t, err)
+
+	future := NewRecipeDeleted("server", "12345")
+	future.Version = SchemaVersion + 1
+	unsupported, err := Encode(future, Protobuf)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		msg    streamer.Message
+		decode func(streamer.Message) (Event, error)
+		err    bool
+	}{
+		{
+			name: "wrong event type",
+			msg:  *saved,
+			decode: func(msg streamer.Message) (Event, error) {
+				return DecodeRecipeDeleted(msg)
+			},
+			err: true,
+		},
+		{
+			name:   "unsupported schema version",
+			msg:    *unsupported,
+			decode: Decode,
+			err:    true,
+		},
+		{
+			name:   "not an event",
+			msg:    streamer.Message{Payload: "12345"},
+			decode: Decode,
+			err:    true,
+		},
+		{
+			name:   "any event",
+			msg:    *saved,
+			decode: Decode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.decode(tt.msg)
+			if !tt.err {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.True(t, errors.IsPermanent(err))
+		})
+	}
+}
+
+// TestDecodeV1 makes sure that messages produced with version 1 of the
+// schema can still be read.
+func TestDecodeV1(t *testing.T) {
+	require := require.New(t)
+
+	b, err := ioutil.ReadFile("testdata/recipe_saved.v1.json")
+	require.NoError(err)
+	var raw struct {
+		Payload json.RawMessage `json:"payload"`
+	}
+	require.NoError(json.Unmarshal(b, &raw))
+
+	e, err := DecodeRecipeSaved(streamer.Message{RawPayload: raw.Payload})
+	require.NoError(err)
+	require.Equal(1, e.Version)
+	require.Equal("server", e.Source)
+	require.Equal(time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC), e.Timestamp)
+	require.Equal("12345", e.Key())
+	require.Equal("0x2a", e.Recipe.ID)
+	require.Equal(float64(500), e.Recipe.Ingredients[0].Quantity)
+}
@@ -0,0 +1,219 @@
+package events
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+
+	"gospiga/pkg/types"
+	pb "gospiga/proto"
+)
+
+// newProto returns the empty protobuf message of the given event.
+func newProto(e Event) (proto.Message, error) {
+	switch e.(type) {
+	case *RecipeCreated:
+		return &pb.RecipeCreated{}, nil
+	case *RecipeUpdated:
+		return &pb.RecipeUpdated{}, nil
+	case *RecipeDeleted:
+		return &pb.RecipeDeleted{}, nil
+	case *RecipeSaved:
+		return &pb.RecipeSaved{}, nil
+	}
+	return nil, fmt.Errorf("no protobuf message for event %s", e.Type())
+}
+
+func toProto(e Event) (proto.Message, error) {
+	meta, err := metaToProto(e.Metadata())
+	if err != nil {
+		return nil, err
+	}
+
+	switch e := e.(type) {
+	case *RecipeCreated:
+		return &pb.RecipeCreated{Meta: meta, RecipeId: e.RecipeID, JobId: e.JobID}, nil
+	case *RecipeUpdated:
+		return &pb.RecipeUpdated{Meta: meta, RecipeId: e.RecipeID}, nil
+	case *RecipeDeleted:
+		return &pb.RecipeDeleted{Meta: meta, RecipeId: e.RecipeID}, nil
+	case *RecipeSaved:
+		return &pb.RecipeSaved{Meta: meta, Recipe: recipeToProto(e.Recipe)}, nil
+	}
+	return nil, fmt.Errorf("no protobuf message for event %s", e.Type())
+}
+
+func fromProto(m proto.Message, e Event) error {
+	var err error
+	switch e := e.(type) {
+	case *RecipeCreated:
+		pm := m.(*pb.RecipeCreated)
+		e.Meta, err = metaFromProto(pm.GetMeta())
+		e.RecipeID = pm.GetRecipeId()
+		e.JobID = pm.GetJobId()
+	case *RecipeUpdated:
+		pm := m.(*pb.RecipeUpdated)
+		e.Meta, err = metaFromProto(pm.GetMeta())
+		e.RecipeID = pm.GetRecipeId()
+	case *RecipeDeleted:
+		pm := m.(*pb.RecipeDeleted)
+		e.Meta, err = metaFromProto(pm.GetMeta())
+		e.RecipeID = pm.GetRecipeId()
+	case *RecipeSaved:
+		pm := m.(*pb.RecipeSaved)
+		e.Meta, err = metaFromProto(pm.GetMeta())
+		e.Recipe = recipeFromProto(pm.GetRecipe())
+	default:
+		err = fmt.Errorf("no protobuf message for event %s", e.Type())
+	}
+	return err
+}
+
+func metaToProto(m Meta) (*pb.EventMeta, error) {
+	ts, err := ptypes.TimestampProto(m.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.EventMeta{
+		Version:   int32(m.Version),
+		Id:        m.ID,
+		Timestamp: ts,
+		Source:    m.Source,
+	}, nil
+}
+
+func metaFromProto(m *pb.EventMeta) (Meta, error) {
+	if m == nil {
+		return Meta{}, fmt.Errorf("missing event metadata")
+	}
+	ts, err := ptypes.Timestamp(m.GetTimestamp())
+	if err != nil {
+		return Meta{}, err
+	}
+	return Meta{
+		Version:   int(m.GetVersion()),
+		ID:        m.GetId(),
+		Timestamp: ts,
+		Source:    m.GetSource(),
+	}, nil
+}
+
+func recipeToProto(r *types.Recipe) *pb.Recipe {
+	if r == nil {
+		return nil
+	}
+
+	pr := &pb.Recipe{
+		Id:          r.ID,
+		ExternalId:  r.ExternalID,
+		Title:       r.Title,
+		Subtitle:    r.Subtitle,
+		MainImage:   imageToProto(r.MainImage),
+		Likes:       int32(r.Likes),
+		Difficulty:  string(r.Difficulty),
+		Cost:        string(r.Cost),
+		PrepTime:    int32(r.PrepTime),
+		CookTime:    int32(r.CookTime),
+		Servings:    int32(r.Servings),
+		ExtraNotes:  r.ExtraNotes,
+		Description: r.Description,
+		Tags:        r.Tags,
+		Conclusion:  r.Conclusion,
+		Slug:        r.Slug,
+	}
+	for _, i := range r.Ingredients {
+		pr.Ingredients = append(pr.Ingredients, &pb.Ingredient{
+			Name:          i.Name,
+			Quantity:      quantityToProto(i.Quantity),
+			UnitOfMeasure: i.UnitOfMeasure,
+		})
+	}
+	for _, s := range r.Steps {
+		pr.Steps = append(pr.Steps, &pb.Step{
+			Heading: s.Heading,
+			Body:    s.Body,
+			Image:   imageToProto(s.Image),
+		})
+	}
+	return pr
+}
+
+func recipeFromProto(pr *pb.Recipe) *types.Recipe {
+	if pr == nil {
+		return nil
+	}
+
+	r := &types.Recipe{
+		ID:          pr.GetId(),
+		ExternalID:  pr.GetExternalId(),
+		Title:       pr.GetTitle(),
+		Subtitle:    pr.GetSubtitle(),
+		MainImage:   imageFromProto(pr.GetMainImage()),
+		Likes:       int(pr.GetLikes()),
+		Difficulty:  types.RecipeDifficulty(pr.GetDifficulty()),
+		Cost:        types.RecipeCost(pr.GetCost()),
+		PrepTime:    int(pr.GetPrepTime()),
+		CookTime:    int(pr.GetCookTime()),
+		Servings:    int(pr.GetServings()),
+		ExtraNotes:  pr.GetExtraNotes(),
+		Description: pr.GetDescription(),
+		Tags:        pr.GetTags(),
+		Conclusion:  pr.GetConclusion(),
+		Slug:        pr.GetSlug(),
+	}
+	for _, i := range pr.GetIngredients() {
+		r.Ingredients = append(r.Ingredients, &types.Ingredient{
+			Name:          i.GetName(),
+			Quantity:      quantityFromProto(i.GetQuantity()),
+			UnitOfMeasure: i.GetUnitOfMeasure(),
+		})
+	}
+	for _, s := range pr.GetSteps() {
+		r.Steps = append(r.Steps, &types.Step{
+			Heading: s.GetHeading(),
+			Body:    s.GetBody(),
+			Image:   imageFromProto(s.GetImage()),
+		})
+	}
+	return r
+}
+
+func imageToProto(i *types.Image) *pb.Image {
+	if i == nil {
+		return nil
+	}
+	return &pb.Image{Url: i.URL}
+}
+
+func imageFromProto(i *pb.Image) *types.Image {
+	if i == nil {
+		return nil
+	}
+	return &types.Image{URL: i.GetUrl()}
+}
+
+// quantityToProto formats the ingredient quantity, that the provider sends
+// either as a number or as a string.
+func quantityToProto(q interface{}) string {
+	switch q := q.(type) {
+	case nil:
+		return ""
+	case string:
+		return q
+	case float64:
+		return strconv.FormatFloat(q, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(q)
+	}
+	return fmt.Sprint(q)
+}
+
+// quantityFromProto returns the quantity as a string, or nil if missing.
+func quantityFromProto(q string) interface{} {
+	if q == "" {
+		return nil
+	}
+	return q
+}
@@ -0,0 +1,52 @@
+package httperr
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gospiga/pkg/errors"
+	"gospiga/pkg/log"
+)
+
+// Body is the JSON body of the error responses.
+type Body struct {
+	Code    errors.Kind `json:"code"`
+	Message string      `json:"message"`
+}
+
+var statuses = map[errors.Kind]int{
+	errors.KindInternal:     http.StatusInternalServerError,
+	errors.KindNotFound:     http.StatusNotFound,
+	errors.KindValidation:   http.StatusBadRequest,
+	errors.KindConflict:     http.StatusConflict,
+	errors.KindUnavailable:  http.StatusServiceUnavailable,
+	errors.KindUnauthorized: http.StatusUnauthorized,
+}
+
+// Handler writes the last error recorded by the handlers, with the status
+// code matching its kind. Internal errors are logged, not sent.
+func Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		kind := errors.KindOf(err)
+		msg := err.Error()
+		if kind == errors.KindInternal {
+			log.Errorf("error handling %s %s: %s", c.Request.Method, c.Request.URL.Path, err)
+			msg = http.StatusText(http.StatusInternalServerError)
+		}
+		c.JSON(statuses[kind], Body{Code: kind, Message: msg})
+	}
+}
+
+// Abort records err and stops the handlers, the response is written by
+// Handler.
+func Abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
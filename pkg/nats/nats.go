@@ -0,0 +1,9 @@
+package nats
+
+import (
+	nats "github.com/nats-io/nats.go"
+)
+
+func NewConn(url string) (*nats.Conn, error) {
+	return nats.Connect(url, nats.MaxReconnects(-1))
+}
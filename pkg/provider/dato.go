@@ -3,9 +3,11 @@ package provider
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jaylane/graphql"
 
+	"gospiga/pkg/errors"
 	"gospiga/pkg/log"
 	"gospiga/pkg/types"
 )
@@ -73,6 +75,9 @@ func (p *provider) GetRecipe(ctx context.Context, recipeID string) (*types.Recip
 	if err != nil {
 		return nil, err
 	}
+	if r.Recipe.ExternalID == "" {
+		return nil, errors.Permanent(fmt.Errorf("recipe ID %s not found", recipeID))
+	}
 
 	return &r.Recipe.Recipe, nil
 }
@@ -80,6 +85,85 @@ func (p *provider) GetRecipe(ctx context.Context, recipeID string) (*types.Recip
 func (p *provider) GetAllRecipeIDs(ctx context.Context) ([]string, error) {
 	log.Infof("Asking dato for all recipe IDs")
 
+	recipes, err := p.allRecipes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(recipes))
+	for _, r := range recipes {
+		ids = append(ids, r.ID)
+	}
+	return ids, nil
+}
+
+// GetRecipeVersions returns the time each recipe was last updated, keyed by
+// recipe ID.
+func (p *provider) GetRecipeVersions(ctx context.Context) (map[string]time.Time, error) {
+	log.Infof("Asking dato for all recipe versions")
+
+	recipes, err := p.allRecipes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]time.Time, len(recipes))
+	for _, r := range recipes {
+		versions[r.ID] = r.UpdatedAt
+	}
+	return versions, nil
+}
+
+// recipesPage is the max no. of records dato returns at once.
+const recipesPage = 100
+
+type recipeVersion struct {
+	ID        string
+	UpdatedAt time.Time
+}
+
+// allRecipes returns the ID and last update of all the recipes, a page at
+// a time. The list is checked against the count of the recipes, so that a
+// partial one is an error.
+func (p *provider) allRecipes(ctx context.Context) ([]recipeVersion, error) {
+	count, err := p.countRecipes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := make([]recipeVersion, 0, count)
+	for len(recipes) < count {
+		req := graphql.NewRequest(`
+			query MyQuery($first: IntType!, $skip: IntType!){
+				recipes: allRecipes(first: $first, skip: $skip, orderBy: [_createdAt_ASC]) {
+					id
+					updatedAt: _updatedAt
+				}
+			}
+		`)
+
+		req.Var("first", recipesPage)
+		req.Var("skip", len(recipes))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.token))
+
+		var r struct {
+			Recipes []recipeVersion
+		}
+		err = p.client.Run(ctx, req, &r)
+		if err != nil {
+			return nil, err
+		}
+		if len(r.Recipes) == 0 {
+			break
+		}
+		recipes = append(recipes, r.Recipes...)
+	}
+
+	if len(recipes) != count {
+		return nil, errors.Unavailable(fmt.Errorf("got %d recipes out of %d", len(recipes), count))
+	}
+	return recipes, nil
+}
+
+func (p *provider) countRecipes(ctx context.Context) (int, error) {
 	req := graphql.NewRequest(`
 		query MyQuery {
 			recipesCount: _allRecipesMeta {
@@ -97,33 +181,7 @@ func (p *provider) GetAllRecipeIDs(ctx context.Context) ([]string, error) {
 	}
 	err := p.client.Run(ctx, req, &c)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-
-	req = graphql.NewRequest(`
-		query MyQuery($first: IntType!){
-			recipes: allRecipes(first: $first) {
-				id
-			}
-		}
-	`)
-
-	req.Var("first", c.RecipesCount.Count)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.token))
-
-	var r struct {
-		Recipes []struct {
-			ID string
-		}
-	}
-	err = p.client.Run(ctx, req, &r)
-	if err != nil {
-		return nil, err
-	}
-
-	ids := make([]string, 0, len(r.Recipes))
-	for _, r := range r.Recipes {
-		ids = append(ids, r.ID)
-	}
-	return ids, nil
+	return c.RecipesCount.Count, nil
 }
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jaylane/graphql"
+	"github.com/stretchr/testify/require"
+
+	"gospiga/pkg/errors"
+)
+
+// fakeDato serves count recipes, up to max at a time, and stops at last.
+func fakeDato(count, max, last int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string
+			Variables struct{ First, Skip int }
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(req.Query, "_allRecipesMeta") {
+			fmt.Fprintf(w, `{"data":{"recipesCount":{"count":%d}}}`, count)
+			return
+		}
+		if req.Variables.First > max {
+			req.Variables.First = max
+		}
+		var recipes []string
+		for i := req.Variables.Skip; i < req.Variables.Skip+req.Variables.First && i < last; i++ {
+			recipes = append(recipes, fmt.Sprintf(`{"id":"r%d","updatedAt":"2020-01-02T15:04:05Z"}`, i))
+		}
+		fmt.Fprintf(w, `{"data":{"recipes":[%s]}}`, strings.Join(recipes, ","))
+	}))
+}
+
+func TestGetAllRecipeIDs(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		last  int
+		err   errors.Kind
+	}{
+		{
+			name:  "all pages",
+			count: 250,
+			last:  250,
+		},
+		{
+			name:  "partial list",
+			count: 250,
+			last:  120,
+			err:   errors.KindUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			srv := fakeDato(tt.count, recipesPage, tt.last)
+			defer srv.Close()
+			p := &provider{client: graphql.NewClient(srv.URL)}
+
+			ids, err := p.GetAllRecipeIDs(context.Background())
+			if tt.err != "" {
+				require.Equal(tt.err, errors.KindOf(err))
+				return
+			}
+			require.NoError(err)
+			require.Len(ids, tt.count)
+			require.Equal("r249", ids[249])
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package streamer
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	errs "gospiga/pkg/errors"
+)
+
+// ErrCircuitOpen is returned instead of calling a streamer deemed unhealthy.
+var ErrCircuitOpen = errs.Unavailable(errors.New("streamer circuit breaker open"))
+
+// Breaker stops calling the streamer after consecutive failures, failing fast
+// until it's likely to be healthy again.
+type Breaker struct {
+	// Threshold is the no. of consecutive failures that opens the breaker.
+	// Zero disables it.
+	Threshold int
+	// Cooldown is the time the breaker stays open before letting a call
+	// through to probe the streamer.
+	Cooldown time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a call can go through and whether it's the probe.
+// Once the cooldown is over a single call is let through, the breaker closes
+// if it succeeds. The probe must be ended with record or release.
+func (b *Breaker) allow() (ok, probe bool) {
+	if b == nil {
+		return true, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.Threshold <= 0 || b.failures < b.Threshold {
+		return true, false
+	}
+	if b.probing || time.Since(b.openedAt) < b.Cooldown {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+// release ends the probe without an outcome, letting the next call probe.
+func (b *Breaker) release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// record the outcome of a call let through.
+func (b *Breaker) record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.Threshold {
+		b.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,175 @@
+package streamer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// bulkChunk is the no. of messages sent at once by AddMany.
+const bulkChunk = 100
+
+// BulkError reports the messages of a batch that could not be added, keyed by
+// their position in the batch.
+type BulkError map[int]error
+
+func (e BulkError) Error() string {
+	return fmt.Sprintf("%d message(s) not added", len(e))
+}
+
+// BulkResult reports the messages of a batch added by AddMany.
+type BulkResult struct {
+	// Added is the no. of messages added.
+	Added int
+	// Skipped holds the position in the batch of the messages skipped, as
+	// added by a previous call.
+	Skipped []int
+}
+
+// bulkAdder sends chunks of messages and keeps the keys of the messages of
+// the batches added so far.
+type bulkAdder interface {
+	// addChunk adds the messages to the stream, returning the error of each
+	// of them.
+	addChunk(stream string, msgs []*Message) []error
+	addedKeys(stream, batch string) (map[string]bool, error)
+	addKeys(stream, batch string, keys []string) error
+	clearKeys(stream, batch string) error
+}
+
+// addedKey returns the key of the set of the keys of the messages of the
+// batch added to the stream.
+func addedKey(stream, batch string) string {
+	return fmt.Sprintf("%s:added:%s", stream, batch)
+}
+
+// addMany adds the messages to the stream in chunks, carrying on after
+// failures. Unless batch is empty, it skips the messages added by a previous
+// call for the same batch: the keys of the messages added are recorded after
+// each chunk, and cleared once the whole batch is added. Messages with no key
+// are always added.
+func addMany(s bulkAdder, stream, batch string, msgs []*Message) (*BulkResult, error) {
+	res := &BulkResult{}
+	added := map[string]bool{}
+	if batch != "" {
+		var err error
+		added, err = s.addedKeys(stream, batch)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	// positions of the messages to add
+	todo := make([]int, 0, len(msgs))
+	for i, msg := range msgs {
+		if msg.Key != "" && added[msg.Key] {
+			res.Skipped = append(res.Skipped, i)
+			continue
+		}
+		todo = append(todo, i)
+	}
+
+	failed := BulkError{}
+	for i := 0; i < len(todo); i += bulkChunk {
+		end := i + bulkChunk
+		if end > len(todo) {
+			end = len(todo)
+		}
+		chunk := make([]*Message, 0, end-i)
+		for _, pos := range todo[i:end] {
+			chunk = append(chunk, msgs[pos])
+		}
+
+		var keys []string
+		for j, err := range s.addChunk(stream, chunk) {
+			pos := todo[i+j]
+			if err != nil {
+				failed[pos] = err
+				continue
+			}
+			res.Added++
+			if msgs[pos].Key != "" {
+				keys = append(keys, msgs[pos].Key)
+			}
+		}
+
+		if batch == "" || len(keys) == 0 {
+			continue
+		}
+		err := s.addKeys(stream, batch, keys)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	if len(failed) > 0 {
+		return res, failed
+	}
+	if batch != "" {
+		err := s.clearKeys(stream, batch)
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// AddMany adds the messages to the stream, pipelining them in chunks, and
+// returns the messages added along with a BulkError reporting the ones that
+// failed. A batch added again with the same name skips the messages whose
+// key was added already, use an empty name to add all of them.
+func (s *redisStreamer) AddMany(stream, batch string, msgs []*Message) (*BulkResult, error) {
+	return addMany(s, stream, batch, msgs)
+}
+
+func (s *redisStreamer) addChunk(stream string, msgs []*Message) []error {
+	errs := make([]error, len(msgs))
+	cmds := make([]*redis.StringCmd, len(msgs))
+
+	pipe := s.rdb.Pipeline()
+	for i, msg := range msgs {
+		jmsg, err := json.Marshal(msg)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		cmds[i] = pipe.XAdd(&redis.XAddArgs{
+			Stream: stream,
+			Values: map[string]interface{}{"message": string(jmsg)},
+		})
+	}
+	// errors are reported by each command
+	pipe.Exec()
+
+	for i, cmd := range cmds {
+		if cmd != nil {
+			errs[i] = cmd.Err()
+		}
+	}
+	return errs
+}
+
+func (s *redisStreamer) addedKeys(stream, batch string) (map[string]bool, error) {
+	keys, err := s.rdb.SMembers(addedKey(stream, batch)).Result()
+	if err != nil {
+		return nil, err
+	}
+	added := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		added[k] = true
+	}
+	return added, nil
+}
+
+func (s *redisStreamer) addKeys(stream, batch string, keys []string) error {
+	members := make([]interface{}, len(keys))
+	for i, k := range keys {
+		members[i] = k
+	}
+	return s.rdb.SAdd(addedKey(stream, batch), members...).Err()
+}
+
+func (s *redisStreamer) clearKeys(stream, batch string) error {
+	return s.rdb.Del(addedKey(stream, batch)).Err()
+}
@@ -0,0 +1,79 @@
+package streamer
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v7"
+
+	"gospiga/pkg/log"
+)
+
+// claimCount is the max no. of pending entries scanned on each XPENDING call.
+const claimCount = 10
+
+// claimStale claims the messages idle for longer than args.ClaimIdle on each
+// stream and delivers them again. XPENDING and XCLAIM are used in place of
+// XAUTOCLAIM, which needs Redis 6.2.
+func (s *redisStreamer) claimStale(ctx context.Context, args *StreamArgs) {
+	for _, stream := range args.Streams() {
+		start := "-"
+		for {
+			next, msgs, err := s.claimPending(stream, args, start)
+			if err != nil {
+				log.Errorf("error claiming pending messages on stream %q: %s", stream, err)
+				break
+			}
+			if len(msgs) > 0 {
+				log.Debugf("Consumer %q claimed %d stale message(s) on stream %q", args.Consumer, len(msgs), stream)
+				s.deliver(ctx, args, redis.XStream{Stream: stream, Messages: msgs}, true)
+			}
+			if next == "" {
+				break
+			}
+			start = next
+		}
+	}
+}
+
+// claimPending claims the stale messages among the pending ones from start
+// on, returning the ID to continue from, empty when none are left.
+func (s *redisStreamer) claimPending(stream string, args *StreamArgs, start string) (string, []redis.XMessage, error) {
+	pending, err := s.rdb.XPendingExt(&redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  args.Group,
+		Start:  start,
+		End:    "+",
+		Count:  claimCount,
+	}).Result()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var ids []string
+	for _, p := range pending {
+		if p.Idle >= args.ClaimIdle {
+			ids = append(ids, p.ID)
+		}
+	}
+	var next string
+	if len(pending) == claimCount {
+		next = nextID(pending[len(pending)-1].ID)
+	}
+	if len(ids) == 0 {
+		return next, nil, nil
+	}
+
+	// XCLAIM checks the idle time again, so that a message is claimed once
+	// when several consumers go for it
+	msgs, err := s.rdb.XClaim(&redis.XClaimArgs{
+		Stream:   stream,
+		Group:    args.Group,
+		Consumer: args.Consumer,
+		MinIdle:  args.ClaimIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return "", nil, err
+	}
+	return next, msgs, nil
+}
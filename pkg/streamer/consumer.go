@@ -0,0 +1,299 @@
+package streamer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gospiga/pkg/errors"
+	"gospiga/pkg/log"
+)
+
+// Handler processes a message read from a stream. Returning nil acknowledges
+// the message, any other error gets it retried according to the retry policy
+// of the stream, or moved to the dead-letter stream once retries are over or
+// if the error is permanent. Handlers are free to acknowledge the message on
+// their own, e.g. relaying it with AckAndAdd.
+type Handler func(ctx context.Context, msg Message) error
+
+// Mode in which the consumers of a group read the streams.
+type Mode int
+
+const (
+	// WorkSharing consumers share the messages of the group, each message is
+	// handled by a single consumer.
+	WorkSharing Mode = iota
+	// Broadcast consumers get every message, reading as a group of their
+	// own, created at the end of the streams and destroyed once done.
+	Broadcast
+)
+
+// broadcastPrefix prefixes the names of the groups of broadcast consumers.
+const broadcastPrefix = "broadcast:"
+
+// StreamArgs required to deal with streams.
+type StreamArgs struct {
+	Group    string
+	Consumer string
+	// Mode defaults to WorkSharing. Broadcast consumers read as the group
+	// named after Group and Consumer.
+	Mode Mode
+	// Handlers to process the messages of each stream, keyed by stream.
+	Handlers map[string]Handler
+	// Retries holds the retry policy of each stream. Messages of streams
+	// without a policy stay pending on failure, until they are claimed again.
+	Retries map[string]*RetryPolicy
+	// MaxAttempts is the number of deliveries after which a message still
+	// pending is moved to the dead-letter stream. Zero means no limit.
+	MaxAttempts int
+	// ClaimIdle is the time after which a pending message, owned by any
+	// consumer of the group, is claimed and delivered again. It should be
+	// larger than the time needed to process a message. Zero disables it.
+	ClaimIdle time.Duration
+	// ClaimInterval between two claims. Defaults to ClaimIdle.
+	ClaimInterval time.Duration
+	// Heartbeat is the interval at which the consumer signals it is alive.
+	// Live consumers remove from the group the ones silent for DeadAfter,
+	// taking over their pending messages. Zero disables it.
+	Heartbeat time.Duration
+	// DeadAfter defaults to three heartbeats.
+	DeadAfter time.Duration
+	// Concurrency is the no. of messages handled at the same time. Messages
+	// with the same key are handled one at a time, in order. Defaults to 1.
+	Concurrency int
+	// Key returns the ordering key of a message. Defaults to the message ID,
+	// i.e. no ordering between messages when Concurrency is larger than 1.
+	Key func(Message) string
+	// Inbox records the events handled by the group, skipping the ones
+	// already handled. Nil disables it.
+	Inbox *Inbox
+	// DeadLettered is called with each message moved to the dead-letter
+	// stream, whether its handler ran or not. Messages that cannot be parsed
+	// only carry their ID and stream. Nil disables it.
+	DeadLettered func(msg Message, reason string)
+	// Weights holds the share of each stream on every read: a stream
+	// weighing 10 gets up to ten times the messages of a stream weighing 1,
+	// so that a busy low priority stream does not starve the others.
+	// Defaults to 1.
+	Weights map[string]int
+
+	pool  *workerPool
+	stats *consumerStats
+}
+
+// Streams returns the streams to read from, in a stable order.
+func (a *StreamArgs) Streams() []string {
+	streams := make([]string, 0, len(a.Handlers))
+	for stream := range a.Handlers {
+		streams = append(streams, stream)
+	}
+	sort.Strings(streams)
+	return streams
+}
+
+// Stats returns the stats of the consumer reading with these args.
+func (a *StreamArgs) Stats() Stats {
+	if a.stats == nil {
+		return Stats{}
+	}
+	return Stats{
+		Started:  a.stats.started,
+		Handled:  atomic.LoadInt64(&a.stats.handled),
+		Failed:   atomic.LoadInt64(&a.stats.failed),
+		InFlight: atomic.LoadInt64(&a.stats.inFlight),
+	}
+}
+
+// join sets the group of the consumer according to the mode, returning the
+// ID from which a new group starts reading.
+func (a *StreamArgs) join() string {
+	if a.Mode != Broadcast {
+		return "0-0"
+	}
+	if !isBroadcastGroup(a.Group) {
+		a.Group = broadcastPrefix + a.Group + ":" + a.Consumer
+	}
+	return "$"
+}
+
+// isBroadcastGroup reports whether the group belongs to a broadcast consumer.
+func isBroadcastGroup(group string) bool {
+	return strings.HasPrefix(group, broadcastPrefix)
+}
+
+// start sets up the workers handling the messages.
+func (a *StreamArgs) start() {
+	a.pool = newWorkerPool(a.Concurrency)
+	a.stats = &consumerStats{started: time.Now()}
+}
+
+// stop waits for the messages being handled.
+func (a *StreamArgs) stop() {
+	a.pool.close()
+}
+
+func (a *StreamArgs) key(msg Message) string {
+	if a.Key != nil {
+		return a.Key(msg)
+	}
+	return msg.ID
+}
+
+// lane is a set of streams sharing the same weight.
+type lane struct {
+	streams []string
+	count   int64
+}
+
+// lanes groups the streams by weight, heaviest first. Each lane reads up to
+// count messages per stream, proportional to its weight.
+func (a *StreamArgs) lanes() []lane {
+	byWeight := make(map[int][]string)
+	var weights []int
+	for _, stream := range a.Streams() {
+		w := a.weight(stream)
+		if _, ok := byWeight[w]; !ok {
+			weights = append(weights, w)
+		}
+		byWeight[w] = append(byWeight[w], stream)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(weights)))
+
+	lanes := make([]lane, 0, len(weights))
+	for _, w := range weights {
+		count := int64(readCount * w / weights[0])
+		if count < 1 {
+			count = 1
+		}
+		lanes = append(lanes, lane{streams: byWeight[w], count: count})
+	}
+	return lanes
+}
+
+func (a *StreamArgs) weight(stream string) int {
+	if w := a.Weights[stream]; w > 0 {
+		return w
+	}
+	return 1
+}
+
+func (a *StreamArgs) deadAfter() time.Duration {
+	if a.DeadAfter > 0 {
+		return a.DeadAfter
+	}
+	return 3 * a.Heartbeat
+}
+
+// deadLettered reports the message moved to the dead-letter stream.
+func (a *StreamArgs) deadLettered(msg Message, reason string) {
+	if a.DeadLettered != nil {
+		a.DeadLettered(msg, reason)
+	}
+}
+
+// deadLetteredData reports the message stored as data, moved to the
+// dead-letter stream, parsing it if possible.
+func (a *StreamArgs) deadLetteredData(data []byte, id, stream, reason string) {
+	if a.DeadLettered == nil {
+		return
+	}
+	msg, err := unmarshalMessage(data, id, stream)
+	if err != nil {
+		msg = &Message{ID: id, Stream: stream}
+	}
+	a.DeadLettered(*msg, reason)
+}
+
+func (a *StreamArgs) claimInterval() time.Duration {
+	if a.ClaimInterval > 0 {
+		return a.ClaimInterval
+	}
+	return a.ClaimIdle
+}
+
+// settler settles the outcome of a handled message.
+type settler interface {
+	Ack(stream, group string, ids ...string) error
+	Nack(stream, group, id string, reason error) error
+	Retry(group string, msg *Message, delay time.Duration, reason error) error
+	DeadLetter(group string, msg *Message, reason string) error
+	Handled(group, eventID string) (bool, error)
+	AckHandled(group string, msg *Message, eventID string, ttl time.Duration) error
+}
+
+// handle runs the handler of the message stream and settles the message
+// depending on the outcome, returning the handler error.
+func handle(ctx context.Context, s settler, args *StreamArgs, msg Message) error {
+	var eventID string
+	if args.Inbox != nil {
+		eventID = args.Inbox.eventID(msg)
+	}
+	if eventID != "" {
+		done, err := s.Handled(args.Group, eventID)
+		if err != nil {
+			log.Errorf("error checking inbox for msg ID %q: %s", msg.ID, err)
+		}
+		if done {
+			log.Debugf("event %q of msg ID %q already handled", eventID, msg.ID)
+			err = s.Ack(msg.Stream, args.Group, msg.ID)
+			if err != nil {
+				log.Errorf("error on Ack for msg ID %q: %s", msg.ID, err)
+			}
+			return nil
+		}
+	}
+
+	herr := runHandler(ctx, args.Handlers[msg.Stream], msg)
+	if herr == nil {
+		var err error
+		if eventID != "" {
+			err = s.AckHandled(args.Group, &msg, eventID, args.Inbox.TTL)
+		} else {
+			err = s.Ack(msg.Stream, args.Group, msg.ID)
+		}
+		if err != nil {
+			log.Errorf("error on Ack for msg ID %q: %s", msg.ID, err)
+		}
+		return nil
+	}
+	log.Errorf("error handling msg ID %q on stream %q: %s", msg.ID, msg.Stream, herr)
+
+	var err error
+	p, ok := args.Retries[msg.Stream]
+	switch {
+	case errors.IsPermanent(herr) || ok && !p.ShouldRetry(herr, int(msg.Deliveries)):
+		err = s.DeadLetter(args.Group, &msg, herr.Error())
+		if err != nil {
+			log.Errorf("error dead-lettering msg ID %q: %s", msg.ID, err)
+			break
+		}
+		args.deadLettered(msg, herr.Error())
+	case ok:
+		err = s.Retry(args.Group, &msg, p.Backoff(int(msg.Deliveries)), herr)
+		if err != nil {
+			log.Errorf("error scheduling retry for msg ID %q: %s", msg.ID, err)
+		}
+	default:
+		err = s.Nack(msg.Stream, args.Group, msg.ID, herr)
+		if err != nil {
+			log.Errorf("error on Nack for msg ID %q: %s", msg.ID, err)
+		}
+	}
+	return herr
+}
+
+// runHandler calls h turning panics into errors.
+func runHandler(ctx context.Context, h Handler, msg Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+	if h == nil {
+		return errors.Permanent(fmt.Errorf("no handler for stream %q", msg.Stream))
+	}
+	return h(ctx, msg)
+}
@@ -0,0 +1,78 @@
+package streamer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"gospiga/pkg/log"
+)
+
+// Scheduler adds messages to the streams later on, e.g. to publish them on a
+// schedule. Keep in mind that:
+//   - only the redis and memory streamers implement it, the nats one doesn't;
+//   - due messages are moved to a stream only while a consumer is reading it,
+//     up to a read block late, and never on a stream nobody reads;
+//   - messages are added in no particular order when due at the same time,
+//     and get their ID when moved.
+type Scheduler interface {
+	// AddDelayed schedules the message to be added to the stream at the
+	// given time, it becomes visible no earlier than that.
+	AddDelayed(stream string, msg *Message, at time.Time) error
+}
+
+var (
+	_ Scheduler = (*redisStreamer)(nil)
+	_ Scheduler = (*memoryStreamer)(nil)
+)
+
+// delayedKey returns the key of the sorted set holding the messages to be
+// added to the given stream later on.
+func delayedKey(stream string) string {
+	return fmt.Sprintf("%s:delayed", stream)
+}
+
+// AddDelayed schedules the message to be added to the stream at the given
+// time. Due messages are moved to the stream by the consumers reading it, so
+// the message becomes visible no earlier than at.
+func (s *redisStreamer) AddDelayed(stream string, msg *Message, at time.Time) error {
+	jmsg, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	// keep equal messages apart
+	token := make([]byte, 8)
+	rand.Read(token)
+
+	return s.rdb.ZAdd(delayedKey(stream), &redis.Z{
+		Score:  float64(at.UnixNano() / int64(time.Millisecond)),
+		Member: hex.EncodeToString(token) + ":" + string(jmsg),
+	}).Err()
+}
+
+// moveDelayed atomically moves the due delayed messages to the streams of the
+// given args.
+func (s *redisStreamer) moveDelayed(args *StreamArgs) {
+	now := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+
+	for _, stream := range args.Streams() {
+		// run pre-loaded script
+		n, err := s.rdb.EvalSha(
+			moveDelayedLua,
+			[]string{delayedKey(stream), stream},  // KEYS
+			[]string{now, strconv.Itoa(dueCount)}, // ARGV
+		).Int64()
+		if err != nil {
+			log.Errorf("error moving delayed messages on stream %q: %s", stream, err)
+			continue
+		}
+		if n > 0 {
+			log.Debugf("moved %d delayed message(s) on stream %q", n, stream)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package streamer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"gospiga/pkg/errors"
+)
+
+const dlqSuffix = ":dlq"
+
+// DeadLetter is a message that has been moved to a dead-letter stream.
+type DeadLetter struct {
+	// ID of the entry on the dead-letter stream.
+	ID string `json:"id"`
+	// Stream the message was originally sent to.
+	Stream string `json:"stream"`
+	// Group that failed to process the message.
+	Group string `json:"group"`
+	// MessageID is the ID the message had on the original stream.
+	MessageID string `json:"messageID"`
+	Reason    string `json:"reason"`
+	Attempts  int64  `json:"attempts"`
+	// Message holds the raw message as it was read from the original stream.
+	Message  string    `json:"message"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+// DeadLetterStream returns the name of the dead-letter stream of the given
+// stream.
+func DeadLetterStream(stream string) string {
+	return stream + dlqSuffix
+}
+
+// failuresKey returns the key of the hash holding the last failure reason of
+// the messages pending on the given stream and group.
+func failuresKey(stream, group string) string {
+	return fmt.Sprintf("%s:failures:%s", stream, group)
+}
+
+// Nack records the reason why a message could not be processed, leaving it in
+// the pending list so that it can be delivered again.
+func (s *redisStreamer) Nack(stream, group, id string, reason error) error {
+	return s.rdb.HSet(failuresKey(stream, group), id, reason.Error()).Err()
+}
+
+// DeadLetter atomically acknowledges the given message and moves it to the
+// dead-letter stream of the stream it was read from.
+func (s *redisStreamer) DeadLetter(group string, msg *Message, reason string) error {
+	jmsg, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	var attempts int64
+	pending, err := s.rdb.XPendingExt(&redis.XPendingExtArgs{
+		Stream: msg.Stream,
+		Group:  group,
+		Start:  msg.ID,
+		End:    msg.ID,
+		Count:  1,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		attempts = pending[0].RetryCount
+	}
+
+	rawMsg := redis.XMessage{
+		ID:     msg.ID,
+		Values: map[string]interface{}{"message": string(jmsg)},
+	}
+	return s.deadLetter(msg.Stream, group, rawMsg, reason, attempts)
+}
+
+func (s *redisStreamer) deadLetter(stream, group string, rawMsg redis.XMessage, reason string, attempts int64) error {
+	strMsg, ok := rawMsg.Values["message"].(string)
+	if !ok {
+		// keep whatever has been sent for later inspection
+		jv, err := json.Marshal(rawMsg.Values)
+		if err != nil {
+			return err
+		}
+		strMsg = string(jv)
+	}
+
+	// run pre-loaded script
+	_, err := s.rdb.EvalSha(
+		deadLetterLua,
+		[]string{stream, DeadLetterStream(stream), failuresKey(stream, group)},      // KEYS
+		[]string{group, rawMsg.ID, strMsg, reason, strconv.FormatInt(attempts, 10)}, // ARGV
+	).Result()
+	if err == redis.Nil {
+		// message already acknowledged
+		return nil
+	}
+	return err
+}
+
+// DeadLetters lists up to count messages moved to the dead-letter stream of
+// the given stream, oldest first.
+func (s *redisStreamer) DeadLetters(stream string, count int64) ([]*DeadLetter, error) {
+	res, err := s.rdb.XRangeN(DeadLetterStream(stream), "-", "+", count).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	dls := make([]*DeadLetter, 0, len(res))
+	for _, rawMsg := range res {
+		dls = append(dls, parseDeadLetter(rawMsg))
+	}
+	return dls, nil
+}
+
+// GetDeadLetter returns the dead-letter entry matching the given ID, nil if it
+// does not exist.
+func (s *redisStreamer) GetDeadLetter(stream, id string) (*DeadLetter, error) {
+	res, err := s.rdb.XRange(DeadLetterStream(stream), id, id).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return parseDeadLetter(res[0]), nil
+}
+
+// Redrive atomically removes the given entry from the dead-letter stream and
+// sends its message back to the original stream, returning the new message ID.
+func (s *redisStreamer) Redrive(stream, id string) (string, error) {
+	// run pre-loaded script
+	res, err := s.rdb.EvalSha(
+		redriveLua,
+		[]string{DeadLetterStream(stream), stream}, // KEYS
+		[]string{id}, // ARGV
+	).Result()
+	if err == redis.Nil {
+		return "", errors.NotFound(fmt.Errorf("dead letter %q not found on stream %q", id, DeadLetterStream(stream)))
+	}
+	if err != nil {
+		return "", err
+	}
+	newID, _ := res.(string)
+	return newID, nil
+}
+
+func parseDeadLetter(rawMsg redis.XMessage) *DeadLetter {
+	dl := &DeadLetter{ID: rawMsg.ID}
+	dl.Stream, _ = rawMsg.Values["stream"].(string)
+	dl.Group, _ = rawMsg.Values["group"].(string)
+	dl.MessageID, _ = rawMsg.Values["id"].(string)
+	dl.Reason, _ = rawMsg.Values["reason"].(string)
+	dl.Message, _ = rawMsg.Values["message"].(string)
+	if a, ok := rawMsg.Values["attempts"].(string); ok {
+		dl.Attempts, _ = strconv.ParseInt(a, 10, 64)
+	}
+	dl.FailedAt = idTime(rawMsg.ID)
+	return dl
+}
+
+// idTime returns the time encoded in a stream entry ID.
+func idTime(id string) time.Time {
+	ms, err := strconv.ParseInt(strings.SplitN(id, "-", 2)[0], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
@@ -0,0 +1,54 @@
+package streamer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Inbox keeps track of the events handled by a consumer group, so that an
+// event delivered again, or sent twice, is acknowledged without running the
+// handler again.
+type Inbox struct {
+	// TTL of the records of the handled events. It should be larger than the
+	// time an event can be delivered again after being handled. Rewinding
+	// the group skips the events still recorded.
+	TTL time.Duration
+	// EventID returns the ID of the event carried by the message, an empty
+	// ID skips the inbox. Defaults to the message ID.
+	EventID func(Message) string
+}
+
+func (i *Inbox) eventID(msg Message) string {
+	if i.EventID != nil {
+		return i.EventID(msg)
+	}
+	return msg.ID
+}
+
+// inboxKey returns the key recording that the group handled the event.
+func inboxKey(group, eventID string) string {
+	return fmt.Sprintf("%s:inbox:%s", group, eventID)
+}
+
+// Handled reports whether the group has already handled the event.
+func (s *redisStreamer) Handled(group, eventID string) (bool, error) {
+	n, err := s.rdb.Exists(inboxKey(group, eventID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// AckHandled atomically acknowledges the message and records that the group
+// handled the event it carries, for ttl.
+func (s *redisStreamer) AckHandled(group string, msg *Message, eventID string, ttl time.Duration) error {
+	// run pre-loaded script
+	_, err := s.rdb.EvalSha(
+		ackInboxLua,
+		[]string{msg.Stream, inboxKey(group, eventID)},                     // KEYS
+		[]string{group, msg.ID, strconv.FormatInt(ttl.Milliseconds(), 10)}, // ARGV
+	).Result()
+
+	return err
+}
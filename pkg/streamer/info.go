@@ -0,0 +1,93 @@
+package streamer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// StreamInfo reports the state of a stream and of its consumer groups.
+type StreamInfo struct {
+	Stream string       `json:"stream"`
+	Length int64        `json:"length"`
+	Groups []*GroupInfo `json:"groups"`
+}
+
+// GroupInfo reports the state of a consumer group on a stream.
+type GroupInfo struct {
+	Name            string `json:"name"`
+	LastDeliveredID string `json:"lastDeliveredId"`
+	// Pending is the no. of entries delivered and not acknowledged yet.
+	Pending int64 `json:"pending"`
+	// OldestPendingIdle is the time since the oldest pending entry has been
+	// delivered.
+	OldestPendingIdle time.Duration `json:"oldestPendingIdle"`
+	// Consumers holds the no. of pending entries of each consumer.
+	Consumers map[string]int64 `json:"consumers"`
+}
+
+// Info reports the state of the given streams and of their consumer groups.
+func (s *redisStreamer) Info(streams ...string) ([]*StreamInfo, error) {
+	infos := make([]*StreamInfo, 0, len(streams))
+	for _, stream := range streams {
+		info := &StreamInfo{Stream: stream, Groups: []*GroupInfo{}}
+		infos = append(infos, info)
+
+		n, err := s.rdb.Exists(stream).Result()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			continue
+		}
+
+		info.Length, err = s.rdb.XLen(stream).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		groups, err := s.rdb.XInfoGroups(stream).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			gi := &GroupInfo{
+				Name:            g.Name,
+				LastDeliveredID: g.LastDeliveredID,
+				Pending:         g.Pending,
+				Consumers:       map[string]int64{},
+			}
+			info.Groups = append(info.Groups, gi)
+			if g.Pending == 0 {
+				continue
+			}
+
+			summary, err := s.rdb.XPending(stream, g.Name).Result()
+			if err != nil {
+				return nil, err
+			}
+			for c, count := range summary.Consumers {
+				gi.Consumers[c] = count
+			}
+
+			oldest, err := s.rdb.XPendingExt(&redis.XPendingExtArgs{
+				Stream: stream,
+				Group:  g.Name,
+				Start:  "-",
+				End:    "+",
+				Count:  1,
+			}).Result()
+			if err != nil {
+				return nil, err
+			}
+			if len(oldest) > 0 {
+				gi.OldestPendingIdle = oldest[0].Idle
+			}
+		}
+		sort.Slice(info.Groups, func(i, j int) bool {
+			return info.Groups[i].Name < info.Groups[j].Name
+		})
+	}
+	return infos, nil
+}
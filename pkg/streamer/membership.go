@@ -0,0 +1,135 @@
+package streamer
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"gospiga/pkg/log"
+)
+
+// ConsumerName returns a consumer name unique to this instance, made of the
+// given prefix, the pod name or the hostname, and a random suffix.
+func ConsumerName(prefix string) string {
+	host := os.Getenv("POD_NAME")
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%s-%x", prefix, host, suffix)
+}
+
+// consumersKey returns the key of the hash holding the last heartbeat of each
+// consumer of the group.
+func consumersKey(group string) string {
+	return fmt.Sprintf("%s:consumers", group)
+}
+
+// heartbeat registers the consumer as alive.
+func (s *redisStreamer) heartbeat(args *StreamArgs) error {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	return s.rdb.HSet(consumersKey(args.Group), args.Consumer, now).Err()
+}
+
+// cleanupDead removes from the group the consumers whose heartbeat is older
+// than args.DeadAfter, after claiming their pending messages.
+func (s *redisStreamer) cleanupDead(ctx context.Context, args *StreamArgs) {
+	beats, err := s.rdb.HGetAll(consumersKey(args.Group)).Result()
+	if err != nil {
+		log.Errorf("error reading consumers of group %q: %s", args.Group, err)
+		return
+	}
+
+	for consumer, beat := range beats {
+		ms, _ := strconv.ParseInt(beat, 10, 64)
+		last := time.Unix(0, ms*int64(time.Millisecond))
+		if consumer == args.Consumer || time.Since(last) < args.deadAfter() {
+			continue
+		}
+
+		done, err := s.takeOver(ctx, args, consumer)
+		if err != nil {
+			log.Errorf("error taking over consumer %q: %s", consumer, err)
+			continue
+		}
+		if !done {
+			log.Debugf("consumer %q still owns pending messages", consumer)
+			continue
+		}
+		s.rdb.HDel(consumersKey(args.Group), consumer)
+		log.Infof("Consumer %q removed dead consumer %q", args.Consumer, consumer)
+	}
+}
+
+// takeOver claims the messages pending on the dead consumer, delivers them
+// and deletes the consumer from the group of each stream. It reports whether
+// the consumer is gone.
+func (s *redisStreamer) takeOver(ctx context.Context, args *StreamArgs, dead string) (bool, error) {
+	for _, stream := range args.Streams() {
+		for {
+			pending, err := s.rdb.XPendingExt(&redis.XPendingExtArgs{
+				Stream:   stream,
+				Group:    args.Group,
+				Start:    "-",
+				End:      "+",
+				Count:    claimCount,
+				Consumer: dead,
+			}).Result()
+			if err != nil {
+				return false, err
+			}
+			if len(pending) == 0 {
+				break
+			}
+
+			ids := make([]string, 0, len(pending))
+			for _, p := range pending {
+				ids = append(ids, p.ID)
+			}
+			// another consumer taking over at the same time resets the idle
+			// time, so that each message is claimed once
+			msgs, err := s.rdb.XClaim(&redis.XClaimArgs{
+				Stream:   stream,
+				Group:    args.Group,
+				Consumer: args.Consumer,
+				MinIdle:  args.Heartbeat,
+				Messages: ids,
+			}).Result()
+			if err != nil {
+				return false, err
+			}
+			if len(msgs) == 0 {
+				break
+			}
+			log.Debugf("Consumer %q took over %d message(s) of %q on stream %q", args.Consumer, len(msgs), dead, stream)
+			s.deliver(ctx, args, redis.XStream{Stream: stream, Messages: msgs}, true)
+		}
+
+		// deleting a consumer drops its pending messages
+		n, err := s.rdb.XPendingExt(&redis.XPendingExtArgs{
+			Stream:   stream,
+			Group:    args.Group,
+			Start:    "-",
+			End:      "+",
+			Count:    1,
+			Consumer: dead,
+		}).Result()
+		if err != nil {
+			return false, err
+		}
+		if len(n) > 0 {
+			return false, nil
+		}
+		err = s.rdb.XGroupDelConsumer(stream, args.Group, dead).Err()
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
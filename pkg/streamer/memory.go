@@ -0,0 +1,949 @@
+package streamer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"gospiga/pkg/errors"
+	"gospiga/pkg/log"
+)
+
+// memoryStreamer keeps streams in memory, with the same consumer group,
+// pending list and history replay semantics of redisStreamer. Messages do not
+// survive the process.
+type memoryStreamer struct {
+	mu      sync.Mutex
+	streams map[string]*memStream
+	// added is closed and replaced each time a message is added.
+	added chan struct{}
+	// consumers holds the last heartbeat of each consumer, keyed by group.
+	consumers map[string]map[string]time.Time
+	// inbox holds the expiry of the handled events, keyed by group.
+	inbox map[string]map[string]time.Time
+	// delayed holds the messages to be added later on, keyed by stream.
+	delayed map[string][]memDelayed
+	// batches holds the keys of the messages of the batches added, keyed by
+	// addedKey.
+	batches map[string]map[string]bool
+	lastMs  int64
+	seq     int64
+}
+
+type memStream struct {
+	entries []redis.XMessage
+	groups  map[string]*memGroup
+}
+
+type memGroup struct {
+	lastID   string
+	pending  map[string]*memPending
+	retries  map[string]time.Time
+	failures map[string]string
+}
+
+type memPending struct {
+	consumer   string
+	deliveries int64
+	delivered  time.Time
+}
+
+// memDelayed is a message to be added to a stream at the given time.
+type memDelayed struct {
+	at     time.Time
+	strMsg string
+}
+
+// memDelivery is a message about to be delivered to a consumer.
+type memDelivery struct {
+	entry      redis.XMessage
+	deliveries int64
+}
+
+// NewMemoryStreamer returns an instance of memoryStreamer.
+func NewMemoryStreamer() *memoryStreamer {
+	return &memoryStreamer{
+		streams:   make(map[string]*memStream),
+		added:     make(chan struct{}),
+		consumers: make(map[string]map[string]time.Time),
+		inbox:     make(map[string]map[string]time.Time),
+		delayed:   make(map[string][]memDelayed),
+		batches:   make(map[string]map[string]bool),
+	}
+}
+
+func (s *memoryStreamer) Ack(stream, group string, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, err := s.group(stream, group)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		g.ack(id)
+	}
+	return nil
+}
+
+func (s *memoryStreamer) Add(stream string, msg *Message) error {
+	return s.AddContext(context.Background(), stream, msg)
+}
+
+// AddContext sends the message over the stream, unless ctx is done.
+func (s *memoryStreamer) AddContext(ctx context.Context, stream string, msg *Message) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	jmsg, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.add(stream, map[string]interface{}{"message": string(jmsg)})
+	return nil
+}
+
+// AddMany adds the messages to the stream in chunks, as the redis streamer
+// does.
+func (s *memoryStreamer) AddMany(stream, batch string, msgs []*Message) (*BulkResult, error) {
+	return addMany(s, stream, batch, msgs)
+}
+
+func (s *memoryStreamer) addChunk(stream string, msgs []*Message) []error {
+	errs := make([]error, len(msgs))
+	for i, msg := range msgs {
+		errs[i] = s.Add(stream, msg)
+	}
+	return errs
+}
+
+func (s *memoryStreamer) addedKeys(stream, batch string) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	added := make(map[string]bool)
+	for k := range s.batches[addedKey(stream, batch)] {
+		added[k] = true
+	}
+	return added, nil
+}
+
+func (s *memoryStreamer) addKeys(stream, batch string, keys []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	added, ok := s.batches[addedKey(stream, batch)]
+	if !ok {
+		added = make(map[string]bool)
+		s.batches[addedKey(stream, batch)] = added
+	}
+	for _, k := range keys {
+		added[k] = true
+	}
+	return nil
+}
+
+func (s *memoryStreamer) clearKeys(stream, batch string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.batches, addedKey(stream, batch))
+	return nil
+}
+
+// AckAndAdd atomically acknowledges a given message ID from a stream and
+// sends the given message to another stream.
+func (s *memoryStreamer) AckAndAdd(fromStream, toStream, group, id string, msg *Message) error {
+	return s.AckAndAddContext(context.Background(), fromStream, toStream, group, id, msg)
+}
+
+// AckAndAddContext is like AckAndAdd, unless ctx is done.
+func (s *memoryStreamer) AckAndAddContext(ctx context.Context, fromStream, toStream, group, id string, msg *Message) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	jmsg, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, err := s.group(fromStream, group)
+	if err != nil {
+		return err
+	}
+	if g.ack(id) {
+		s.add(toStream, map[string]interface{}{"message": string(jmsg)})
+	}
+	return nil
+}
+
+// AddDelayed schedules the message to be added to the stream at the given
+// time. Due messages are moved to the stream by the consumers reading it, so
+// the message becomes visible no earlier than at.
+func (s *memoryStreamer) AddDelayed(stream string, msg *Message, at time.Time) error {
+	jmsg, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delayed[stream] = append(s.delayed[stream], memDelayed{at: at, strMsg: string(jmsg)})
+	return nil
+}
+
+// ReadGroup reads messages on the streams of the given args as a consumer of
+// the group, handing them to the stream handlers, until ctx is done.
+func (s *memoryStreamer) ReadGroup(ctx context.Context, args *StreamArgs) error {
+	streams := args.Streams()
+	start := args.join()
+
+	s.mu.Lock()
+	for _, stream := range streams {
+		st := s.stream(stream)
+		if _, ok := st.groups[args.Group]; !ok {
+			lastID := "0-0"
+			if start == "$" && len(st.entries) > 0 {
+				lastID = st.entries[len(st.entries)-1].ID
+			}
+			st.groups[args.Group] = &memGroup{
+				lastID:   lastID,
+				pending:  make(map[string]*memPending),
+				retries:  make(map[string]time.Time),
+				failures: make(map[string]string),
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if args.Heartbeat > 0 {
+		s.heartbeat(args)
+	}
+
+	args.start()
+	go func() {
+		defer func() {
+			args.stop()
+			if args.Mode == Broadcast {
+				s.leave(args)
+			}
+		}()
+
+		checkHistory := true
+		var lastClaim time.Time
+		lastBeat := time.Now()
+
+		lastIDs := make(map[string]string, len(streams))
+		for _, stream := range streams {
+			lastIDs[stream] = "0-0"
+		}
+		for {
+			if ctx.Err() != nil {
+				log.Debugf("stop reading streams %s", streams)
+				return
+			}
+
+			if args.Heartbeat > 0 && time.Since(lastBeat) >= args.Heartbeat {
+				lastBeat = time.Now()
+				s.heartbeat(args)
+				s.cleanupDead(ctx, args)
+			}
+			if args.ClaimIdle > 0 && time.Since(lastClaim) >= args.claimInterval() {
+				lastClaim = time.Now()
+				for _, stream := range streams {
+					s.deliver(ctx, args, stream, s.claimStale(stream, args))
+				}
+			}
+			for _, stream := range streams {
+				s.deliver(ctx, args, stream, s.popDue(stream, args))
+			}
+			s.moveDelayed(args)
+
+			s.mu.Lock()
+			added := s.added
+			s.mu.Unlock()
+
+			gotMessage := false
+			for _, l := range args.lanes() {
+				for _, stream := range l.streams {
+					var ds []memDelivery
+					if checkHistory {
+						ds = s.readPending(stream, args, lastIDs[stream])
+						if len(ds) > 0 {
+							lastIDs[stream] = ds[len(ds)-1].entry.ID
+						}
+					} else {
+						ds = s.readNew(stream, args, l.count)
+					}
+					if len(ds) > 0 {
+						gotMessage = true
+						s.deliver(ctx, args, stream, ds)
+					}
+				}
+			}
+
+			if gotMessage {
+				continue
+			}
+			if checkHistory {
+				log.Debugf("done reading history on streams: %v", streams)
+				checkHistory = false
+				continue
+			}
+
+			select {
+			case <-added:
+			case <-ctx.Done():
+			case <-time.After(time.Millisecond * 2000):
+			}
+		}
+	}()
+	return nil
+}
+
+// Nack records the reason why a message could not be processed, leaving it in
+// the pending list so that it can be delivered again.
+func (s *memoryStreamer) Nack(stream, group, id string, reason error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, err := s.group(stream, group)
+	if err != nil {
+		return err
+	}
+	if _, ok := g.pending[id]; ok {
+		g.failures[id] = reason.Error()
+	}
+	return nil
+}
+
+// Retry schedules the given message to be delivered again to the group after
+// delay. The message stays pending meanwhile, reason is recorded as for Nack.
+func (s *memoryStreamer) Retry(group string, msg *Message, delay time.Duration, reason error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, err := s.group(msg.Stream, group)
+	if err != nil {
+		return err
+	}
+	if _, ok := g.pending[msg.ID]; ok {
+		g.failures[msg.ID] = reason.Error()
+		g.retries[msg.ID] = time.Now().Add(delay)
+	}
+	return nil
+}
+
+// DeadLetter atomically acknowledges the given message and moves it to the
+// dead-letter stream of the stream it was read from.
+func (s *memoryStreamer) DeadLetter(group string, msg *Message, reason string) error {
+	jmsg, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var attempts int64
+	if g, err := s.group(msg.Stream, group); err == nil {
+		if p, ok := g.pending[msg.ID]; ok {
+			attempts = p.deliveries
+		}
+	}
+	return s.deadLetter(msg.Stream, group, msg.ID, string(jmsg), reason, attempts)
+}
+
+// DeadLetters lists up to count messages moved to the dead-letter stream of
+// the given stream, oldest first.
+func (s *memoryStreamer) DeadLetters(stream string, count int64) ([]*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.streams[DeadLetterStream(stream)]
+	if !ok {
+		return []*DeadLetter{}, nil
+	}
+	dls := make([]*DeadLetter, 0, len(st.entries))
+	for _, e := range st.entries {
+		if int64(len(dls)) == count {
+			break
+		}
+		dls = append(dls, parseDeadLetter(e))
+	}
+	return dls, nil
+}
+
+// GetDeadLetter returns the dead-letter entry matching the given ID, nil if it
+// does not exist.
+func (s *memoryStreamer) GetDeadLetter(stream, id string) (*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.streams[DeadLetterStream(stream)]
+	if !ok {
+		return nil, nil
+	}
+	if i := st.find(id); i >= 0 {
+		return parseDeadLetter(st.entries[i]), nil
+	}
+	return nil, nil
+}
+
+// Redrive atomically removes the given entry from the dead-letter stream and
+// sends its message back to the original stream, returning the new message ID.
+func (s *memoryStreamer) Redrive(stream, id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dlq := DeadLetterStream(stream)
+	st, ok := s.streams[dlq]
+	if !ok {
+		return "", errors.NotFound(fmt.Errorf("dead letter %q not found on stream %q", id, dlq))
+	}
+	i := st.find(id)
+	if i < 0 {
+		return "", errors.NotFound(fmt.Errorf("dead letter %q not found on stream %q", id, dlq))
+	}
+	strMsg, _ := st.entries[i].Values["message"].(string)
+	st.entries = append(st.entries[:i], st.entries[i+1:]...)
+
+	return s.add(stream, map[string]interface{}{"message": strMsg}), nil
+}
+
+// stream returns the given stream, creating it if needed. Must be called with
+// the lock held.
+func (s *memoryStreamer) stream(name string) *memStream {
+	st, ok := s.streams[name]
+	if !ok {
+		st = &memStream{groups: make(map[string]*memGroup)}
+		s.streams[name] = st
+	}
+	return st
+}
+
+// group returns the given consumer group. Must be called with the lock held.
+func (s *memoryStreamer) group(stream, group string) (*memGroup, error) {
+	st, ok := s.streams[stream]
+	if !ok {
+		return nil, fmt.Errorf("NOGROUP no such key %q or consumer group %q", stream, group)
+	}
+	g, ok := st.groups[group]
+	if !ok {
+		return nil, fmt.Errorf("NOGROUP no such key %q or consumer group %q", stream, group)
+	}
+	return g, nil
+}
+
+// add appends a new entry to the stream and wakes up the readers, returning
+// the entry ID. Must be called with the lock held.
+func (s *memoryStreamer) add(stream string, values map[string]interface{}) string {
+	ms := time.Now().UnixNano() / int64(time.Millisecond)
+	if ms <= s.lastMs {
+		s.seq++
+	} else {
+		s.lastMs, s.seq = ms, 0
+	}
+	id := fmt.Sprintf("%d-%d", s.lastMs, s.seq)
+
+	st := s.stream(stream)
+	st.entries = append(st.entries, redis.XMessage{ID: id, Values: values})
+
+	close(s.added)
+	s.added = make(chan struct{})
+
+	return id
+}
+
+// deadLetter acknowledges the given message and adds it to the dead-letter
+// stream. Must be called with the lock held.
+func (s *memoryStreamer) deadLetter(stream, group, id, strMsg, reason string, attempts int64) error {
+	g, err := s.group(stream, group)
+	if err != nil {
+		return err
+	}
+	if !g.ack(id) {
+		// message already acknowledged
+		return nil
+	}
+
+	s.add(DeadLetterStream(stream), map[string]interface{}{
+		"message":  strMsg,
+		"stream":   stream,
+		"group":    group,
+		"id":       id,
+		"reason":   reason,
+		"attempts": strconv.FormatInt(attempts, 10),
+	})
+	return nil
+}
+
+// readNew delivers to the consumer up to count entries never delivered to
+// its group.
+func (s *memoryStreamer) readNew(stream string, args *StreamArgs, count int64) []memDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.streams[stream]
+	g := st.groups[args.Group]
+
+	var ds []memDelivery
+	for _, e := range st.entries {
+		if int64(len(ds)) == count {
+			break
+		}
+		if CompareIDs(e.ID, g.lastID) <= 0 {
+			continue
+		}
+		g.lastID = e.ID
+		g.pending[e.ID] = &memPending{
+			consumer:   args.Consumer,
+			deliveries: 1,
+			delivered:  time.Now(),
+		}
+		ds = append(ds, memDelivery{entry: e, deliveries: 1})
+	}
+	return ds
+}
+
+// SetGroupID moves the group on the stream to the given ID: the entries after
+// it are delivered again to the group, the ones before are skipped. Pending
+// entries are left untouched.
+func (s *memoryStreamer) SetGroupID(stream, group, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, err := s.group(stream, group)
+	if err != nil {
+		return err
+	}
+	if id == "$" {
+		id = "0-0"
+		if st := s.streams[stream]; len(st.entries) > 0 {
+			id = st.entries[len(st.entries)-1].ID
+		}
+	}
+	g.lastID = id
+	return nil
+}
+
+// Rewind moves the group on the stream back to the given time: the entries
+// added from then on are delivered again to the group.
+func (s *memoryStreamer) Rewind(stream, group string, since time.Time) error {
+	return s.SetGroupID(stream, group, TimeID(since))
+}
+
+// Replay hands the entries of the stream between start and end, both
+// included, to the handler, leaving the groups untouched. Use "-" and "+" for
+// the first and the last entry.
+func (s *memoryStreamer) Replay(ctx context.Context, stream, start, end string, h Handler) (*ReplayStats, error) {
+	s.mu.Lock()
+	var entries []redis.XMessage
+	for _, e := range s.stream(stream).entries {
+		if start != "-" && CompareIDs(e.ID, start) < 0 {
+			continue
+		}
+		if end != "+" && CompareIDs(e.ID, end) > 0 {
+			break
+		}
+		entries = append(entries, e)
+	}
+	s.mu.Unlock()
+
+	stats := &ReplayStats{}
+	for _, e := range entries {
+		if !replay(ctx, h, e, stream, stats) {
+			break
+		}
+	}
+	return stats, ctx.Err()
+}
+
+// Info reports the state of the given streams and of their consumer groups.
+func (s *memoryStreamer) Info(streams ...string) ([]*StreamInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]*StreamInfo, 0, len(streams))
+	for _, stream := range streams {
+		info := &StreamInfo{Stream: stream, Groups: []*GroupInfo{}}
+		infos = append(infos, info)
+
+		st, ok := s.streams[stream]
+		if !ok {
+			continue
+		}
+		info.Length = int64(len(st.entries))
+
+		for name, g := range st.groups {
+			gi := &GroupInfo{
+				Name:            name,
+				LastDeliveredID: g.lastID,
+				Pending:         int64(len(g.pending)),
+				Consumers:       map[string]int64{},
+			}
+			var oldest string
+			for id, p := range g.pending {
+				gi.Consumers[p.consumer]++
+				if oldest == "" || CompareIDs(id, oldest) < 0 {
+					oldest = id
+					gi.OldestPendingIdle = time.Since(p.delivered)
+				}
+			}
+			info.Groups = append(info.Groups, gi)
+		}
+		sort.Slice(info.Groups, func(i, j int) bool {
+			return info.Groups[i].Name < info.Groups[j].Name
+		})
+	}
+	return infos, nil
+}
+
+// Trim applies the retention to the stream, returning the no. of entries
+// reclaimed.
+func (s *memoryStreamer) Trim(stream string, r *Retention) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.streams[stream]
+	if !ok {
+		return 0, nil
+	}
+
+	var lenCut string
+	if r.MaxLen > 0 && int64(len(st.entries)) >= r.MaxLen {
+		lenCut = st.entries[int64(len(st.entries))-r.MaxLen].ID
+	}
+	cut := r.cutoff(time.Now(), lenCut)
+	if cut == "" {
+		return 0, nil
+	}
+
+	if r.AckedOnly {
+		var safe string
+		for name, g := range st.groups {
+			if isBroadcastGroup(name) {
+				continue
+			}
+			id := nextID(g.lastID)
+			for pid := range g.pending {
+				if CompareIDs(pid, id) < 0 {
+					id = pid
+				}
+			}
+			if safe == "" || CompareIDs(id, safe) < 0 {
+				safe = id
+			}
+		}
+		if safe == "" {
+			return 0, nil
+		}
+		if CompareIDs(safe, cut) < 0 {
+			cut = safe
+		}
+	}
+
+	n := 0
+	for n < len(st.entries) && CompareIDs(st.entries[n].ID, cut) < 0 {
+		n++
+	}
+	st.entries = st.entries[n:]
+	return int64(n), nil
+}
+
+// Handled reports whether the group has already handled the event.
+func (s *memoryStreamer) Handled(group, eventID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiry, ok := s.inbox[group][eventID]
+	if ok && !time.Now().Before(expiry) {
+		delete(s.inbox[group], eventID)
+		return false, nil
+	}
+	return ok, nil
+}
+
+// AckHandled atomically acknowledges the message and records that the group
+// handled the event it carries, for ttl.
+func (s *memoryStreamer) AckHandled(group string, msg *Message, eventID string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, err := s.group(msg.Stream, group)
+	if err != nil {
+		return err
+	}
+	handled, ok := s.inbox[group]
+	if !ok {
+		handled = make(map[string]time.Time)
+		s.inbox[group] = handled
+	}
+	now := time.Now()
+	for id, expiry := range handled {
+		if !now.Before(expiry) {
+			delete(handled, id)
+		}
+	}
+	handled[eventID] = now.Add(ttl)
+	g.ack(msg.ID)
+	return nil
+}
+
+// leave removes the group of the consumer from its streams, along with its
+// pending messages.
+func (s *memoryStreamer) leave(args *StreamArgs) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stream := range args.Streams() {
+		delete(s.streams[stream].groups, args.Group)
+	}
+	delete(s.consumers, args.Group)
+	delete(s.inbox, args.Group)
+}
+
+// moveDelayed moves the due delayed messages to the streams of the given
+// args, in the order they are due.
+func (s *memoryStreamer) moveDelayed(args *StreamArgs) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, stream := range args.Streams() {
+		delayed := s.delayed[stream]
+		sort.SliceStable(delayed, func(i, j int) bool {
+			return delayed[i].at.Before(delayed[j].at)
+		})
+		n := 0
+		for n < len(delayed) && !delayed[n].at.After(now) {
+			s.add(stream, map[string]interface{}{"message": delayed[n].strMsg})
+			n++
+		}
+		s.delayed[stream] = delayed[n:]
+	}
+}
+
+// heartbeat registers the consumer as alive.
+func (s *memoryStreamer) heartbeat(args *StreamArgs) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	beats, ok := s.consumers[args.Group]
+	if !ok {
+		beats = make(map[string]time.Time)
+		s.consumers[args.Group] = beats
+	}
+	beats[args.Consumer] = time.Now()
+}
+
+// cleanupDead removes from the group the consumers whose heartbeat is older
+// than args.DeadAfter, delivering their pending messages to the consumer.
+func (s *memoryStreamer) cleanupDead(ctx context.Context, args *StreamArgs) {
+	s.mu.Lock()
+	var dead []string
+	for consumer, last := range s.consumers[args.Group] {
+		if consumer != args.Consumer && time.Since(last) >= args.deadAfter() {
+			dead = append(dead, consumer)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, consumer := range dead {
+		for _, stream := range args.Streams() {
+			for {
+				ds := s.redeliver(stream, args, func(id string, p *memPending) bool {
+					return p.consumer == consumer
+				})
+				if len(ds) == 0 {
+					break
+				}
+				log.Debugf("Consumer %q took over %d message(s) of %q on stream %q", args.Consumer, len(ds), consumer, stream)
+				s.deliver(ctx, args, stream, ds)
+			}
+		}
+
+		s.mu.Lock()
+		delete(s.consumers[args.Group], consumer)
+		s.mu.Unlock()
+		log.Infof("Consumer %q removed dead consumer %q", args.Consumer, consumer)
+	}
+}
+
+// readPending delivers again the entries pending on the consumer after the
+// given ID.
+func (s *memoryStreamer) readPending(stream string, args *StreamArgs, after string) []memDelivery {
+	return s.redeliver(stream, args, func(id string, p *memPending) bool {
+		return p.consumer == args.Consumer && CompareIDs(id, after) > 0
+	})
+}
+
+// claimStale delivers to the consumer the entries idle for longer than
+// args.ClaimIdle, whatever consumer they belong to.
+func (s *memoryStreamer) claimStale(stream string, args *StreamArgs) []memDelivery {
+	return s.redeliver(stream, args, func(id string, p *memPending) bool {
+		return time.Since(p.delivered) >= args.ClaimIdle
+	})
+}
+
+// popDue delivers to the consumer the entries whose retry is due.
+func (s *memoryStreamer) popDue(stream string, args *StreamArgs) []memDelivery {
+	now := time.Now()
+	return s.redeliver(stream, args, func(id string, p *memPending) bool {
+		g := s.streams[stream].groups[args.Group]
+		due, ok := g.retries[id]
+		if !ok || due.After(now) {
+			return false
+		}
+		delete(g.retries, id)
+		return true
+	})
+}
+
+// redeliver assigns to the consumer the pending entries matching the given
+// filter, in ID order, bumping their delivery count.
+func (s *memoryStreamer) redeliver(stream string, args *StreamArgs, filter func(string, *memPending) bool) []memDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.streams[stream]
+	g := st.groups[args.Group]
+
+	ids := make([]string, 0, len(g.pending))
+	for id := range g.pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return CompareIDs(ids[i], ids[j]) < 0
+	})
+
+	var ds []memDelivery
+	for _, id := range ids {
+		if len(ds) == readCount {
+			break
+		}
+		p := g.pending[id]
+		if !filter(id, p) {
+			continue
+		}
+		i := st.find(id)
+		if i < 0 {
+			// entry deleted from the stream
+			g.ack(id)
+			continue
+		}
+		p.consumer = args.Consumer
+		p.deliveries++
+		p.delivered = time.Now()
+		ds = append(ds, memDelivery{entry: st.entries[i], deliveries: p.deliveries})
+	}
+	return ds
+}
+
+// deliver hands the given messages to the workers. Messages that have
+// already been delivered too many times, or that cannot be parsed, are moved
+// to the dead-letter stream.
+func (s *memoryStreamer) deliver(ctx context.Context, args *StreamArgs, stream string, ds []memDelivery) {
+	if len(ds) == 0 {
+		return
+	}
+	log.Debugf("Consumer %q recived %d message(s)", args.Consumer, len(ds))
+
+	for _, d := range ds {
+		strMsg, _ := d.entry.Values["message"].(string)
+
+		if args.MaxAttempts > 0 && d.deliveries > int64(args.MaxAttempts) {
+			s.mu.Lock()
+			reason := s.streams[stream].groups[args.Group].failures[d.entry.ID]
+			if reason == "" {
+				reason = "max attempts exceeded"
+			}
+			err := s.deadLetter(stream, args.Group, d.entry.ID, strMsg, reason, d.deliveries-1)
+			s.mu.Unlock()
+			if err != nil {
+				log.Errorf("error dead-lettering message %q: %s", d.entry.ID, err)
+				continue
+			}
+			args.deadLetteredData([]byte(strMsg), d.entry.ID, stream, reason)
+			continue
+		}
+
+		msg, err := parseMessage(d.entry, stream)
+		if err != nil {
+			log.Errorf(err.Error())
+			// move malformed message out of the way
+			reason := err.Error()
+			s.mu.Lock()
+			err = s.deadLetter(stream, args.Group, d.entry.ID, strMsg, reason, d.deliveries)
+			s.mu.Unlock()
+			if err != nil {
+				log.Errorf("error dead-lettering message %q: %s", d.entry.ID, err)
+				continue
+			}
+			args.deadLettered(Message{ID: d.entry.ID, Stream: stream}, reason)
+			continue
+		}
+		msg.Deliveries = d.deliveries
+
+		dispatch(ctx, args, s, *msg)
+	}
+}
+
+// ack removes the given ID from the pending list, reporting whether it was
+// pending.
+func (g *memGroup) ack(id string) bool {
+	_, ok := g.pending[id]
+	delete(g.pending, id)
+	delete(g.retries, id)
+	delete(g.failures, id)
+	return ok
+}
+
+// find returns the index of the entry matching the given ID, -1 if not found.
+func (st *memStream) find(id string) int {
+	i := sort.Search(len(st.entries), func(i int) bool {
+		return CompareIDs(st.entries[i].ID, id) >= 0
+	})
+	if i < len(st.entries) && st.entries[i].ID == id {
+		return i
+	}
+	return -1
+}
+
+// CompareIDs compares two stream entry IDs, returning -1, 0 or 1.
+func CompareIDs(a, b string) int {
+	ams, aseq := splitID(a)
+	bms, bseq := splitID(b)
+	switch {
+	case ams < bms:
+		return -1
+	case ams > bms:
+		return 1
+	case aseq < bseq:
+		return -1
+	case aseq > bseq:
+		return 1
+	}
+	return 0
+}
+
+func splitID(id string) (int64, int64) {
+	parts := strings.SplitN(id, "-", 2)
+	ms, _ := strconv.ParseInt(parts[0], 10, 64)
+	var seq int64
+	if len(parts) == 2 {
+		seq, _ = strconv.ParseInt(parts[1], 10, 64)
+	}
+	return ms, seq
+}
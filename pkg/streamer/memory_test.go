@@ -0,0 +1,694 @@
+package streamer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	errs "gospiga/pkg/errors"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestArgs(consumer string, handlers map[string]Handler) *StreamArgs {
+	return &StreamArgs{
+		Group:    "test-group",
+		Consumer: consumer,
+		Handlers: handlers,
+	}
+}
+
+// forward returns a handler that sends the messages to the given channel and
+// returns the outcome of fail, if any.
+func forward(ch chan<- Message, fail func(Message) error) Handler {
+	return func(ctx context.Context, msg Message) error {
+		ch <- msg
+		if fail == nil {
+			return nil
+		}
+		return fail(msg)
+	}
+}
+
+// receive waits for the next message handled by the consumer.
+func receive(t *testing.T, ch <-chan Message) Message {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+	return Message{}
+}
+
+func pendingCount(s *memoryStreamer, stream, group string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams[stream].groups[group].pending)
+}
+
+// addFunc turns a function into a ContextAdder.
+type addFunc func(ctx context.Context, stream string, msg *Message) error
+
+func (f addFunc) AddContext(ctx context.Context, stream string, msg *Message) error {
+	return f(ctx, stream, msg)
+}
+
+func TestMemoryStreamer(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s *memoryStreamer)
+	}{
+		{
+			name: "read and ack",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message)
+				args := newTestArgs("c1", map[string]Handler{"s1": forward(ch, nil)})
+				require.NoError(s.ReadGroup(ctx, args))
+
+				msg := receive(t, ch)
+				require.Equal("s1", msg.Stream)
+				require.Equal("p1", msg.Payload)
+				require.Equal(int64(1), msg.Deliveries)
+
+				require.Eventually(func() bool {
+					return pendingCount(s, "s1", args.Group) == 0
+				}, 5*time.Second, 10*time.Millisecond)
+			},
+		},
+		{
+			name: "replay history of pending messages",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				ch := make(chan Message, 1)
+				failing := forward(ch, func(Message) error { return errors.New("boom") })
+				args := newTestArgs("c1", map[string]Handler{"s1": failing})
+				require.NoError(s.ReadGroup(ctx, args))
+				msg := receive(t, ch)
+				cancel()
+
+				ctx, cancel = context.WithCancel(context.Background())
+				defer cancel()
+				args = newTestArgs("c1", map[string]Handler{"s1": forward(ch, nil)})
+				require.NoError(s.ReadGroup(ctx, args))
+
+				replayed := receive(t, ch)
+				require.Equal(msg.ID, replayed.ID)
+				require.Equal(int64(2), replayed.Deliveries)
+			},
+		},
+		{
+			name: "ack and add",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message)
+				args := newTestArgs("c1", nil)
+				args.Handlers = map[string]Handler{
+					"s1": func(ctx context.Context, msg Message) error {
+						return s.AckAndAdd(msg.Stream, "s2", args.Group, msg.ID, &Message{Payload: "p2"})
+					},
+					"s2": forward(ch, nil),
+				}
+				require.NoError(s.ReadGroup(ctx, args))
+
+				relayed := receive(t, ch)
+				require.Equal("s2", relayed.Stream)
+				require.Equal("p2", relayed.Payload)
+				require.Zero(pendingCount(s, "s1", args.Group))
+			},
+		},
+		{
+			name: "dead-letter and redrive",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message, 1)
+				calls := 0
+				args := newTestArgs("c1", map[string]Handler{
+					"s1": forward(ch, func(Message) error {
+						calls++
+						if calls == 1 {
+							return errs.Permanent(errors.New("boom"))
+						}
+						return nil
+					}),
+				})
+				require.NoError(s.ReadGroup(ctx, args))
+
+				msg := receive(t, ch)
+				var dls []*DeadLetter
+				require.Eventually(func() bool {
+					var err error
+					dls, err = s.DeadLetters("s1", 10)
+					return err == nil && len(dls) == 1
+				}, 5*time.Second, 10*time.Millisecond)
+				require.Equal(msg.ID, dls[0].MessageID)
+				require.Equal("boom", dls[0].Reason)
+				require.Equal(int64(1), dls[0].Attempts)
+
+				_, err := s.Redrive("s1", dls[0].ID)
+				require.NoError(err)
+
+				redriven := receive(t, ch)
+				require.Equal("p1", redriven.Payload)
+				dls, err = s.DeadLetters("s1", 10)
+				require.NoError(err)
+				require.Empty(dls)
+			},
+		},
+		{
+			name: "dead-lettered messages reported",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				s.mu.Lock()
+				s.add("s1", map[string]interface{}{"message": "malformed"})
+				s.mu.Unlock()
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				type report struct {
+					stream  string
+					payload interface{}
+					reason  string
+				}
+				reports := make(chan report, 2)
+				args := newTestArgs("c1", map[string]Handler{
+					"s1": func(ctx context.Context, msg Message) error {
+						return errs.Permanent(errors.New("boom"))
+					},
+				})
+				args.DeadLettered = func(msg Message, reason string) {
+					reports <- report{msg.Stream, msg.Payload, reason}
+				}
+				require.NoError(s.ReadGroup(ctx, args))
+
+				for _, want := range []report{{"s1", nil, "malformed"}, {"s1", "p1", "boom"}} {
+					select {
+					case got := <-reports:
+						require.Equal(want.stream, got.stream)
+						require.Equal(want.payload, got.payload)
+						require.Contains(got.reason, want.reason)
+					case <-time.After(5 * time.Second):
+						t.Fatal("timeout waiting for dead-lettered message")
+					}
+				}
+			},
+		},
+		{
+			name: "retry until max attempts",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message, 2)
+				failing := forward(ch, func(msg Message) error {
+					return fmt.Errorf("boom %d", msg.Deliveries)
+				})
+				args := newTestArgs("c1", map[string]Handler{"s1": failing})
+				args.Retries = map[string]*RetryPolicy{
+					"s1": {MaxAttempts: 2, BaseDelay: time.Millisecond},
+				}
+				require.NoError(s.ReadGroup(ctx, args))
+
+				msg := receive(t, ch)
+				retried := receive(t, ch)
+				require.Equal(msg.ID, retried.ID)
+				require.Equal(int64(2), retried.Deliveries)
+
+				require.Eventually(func() bool {
+					dls, err := s.DeadLetters("s1", 10)
+					return err == nil && len(dls) == 1 && dls[0].Reason == "boom 2"
+				}, 5*time.Second, 10*time.Millisecond)
+			},
+		},
+		{
+			name: "concurrent handlers keep key order",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				const perKey = 20
+				keys := []string{"k1", "k2", "k3"}
+				for i := 0; i < perKey; i++ {
+					for _, k := range keys {
+						require.NoError(s.Add("s1", &Message{Payload: fmt.Sprintf("%s:%02d", k, i)}))
+					}
+				}
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				var mu sync.Mutex
+				got := make(map[string][]string)
+				args := newTestArgs("c1", map[string]Handler{
+					"s1": func(ctx context.Context, msg Message) error {
+						p := msg.Payload.(string)
+						mu.Lock()
+						defer mu.Unlock()
+						got[p[:2]] = append(got[p[:2]], p)
+						return nil
+					},
+				})
+				args.Concurrency = 4
+				args.Key = func(msg Message) string {
+					return msg.Payload.(string)[:2]
+				}
+				require.NoError(s.ReadGroup(ctx, args))
+
+				require.Eventually(func() bool {
+					return args.Stats().Handled == int64(perKey*len(keys))
+				}, 5*time.Second, 10*time.Millisecond)
+				mu.Lock()
+				defer mu.Unlock()
+				for _, k := range keys {
+					require.Len(got[k], perKey)
+					require.True(sort.StringsAreSorted(got[k]), "messages of key %s out of order", k)
+				}
+				require.Zero(args.Stats().Failed)
+			},
+		},
+		{
+			name: "dead consumer taken over",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				ch := make(chan Message, 1)
+				failing := forward(ch, func(Message) error { return errors.New("boom") })
+				dead := newTestArgs("c1", map[string]Handler{"s1": failing})
+				dead.Heartbeat = 10 * time.Millisecond
+				require.NoError(s.ReadGroup(ctx, dead))
+				msg := receive(t, ch)
+				cancel()
+
+				ctx, cancel = context.WithCancel(context.Background())
+				defer cancel()
+				live := newTestArgs("c2", map[string]Handler{"s1": forward(ch, nil)})
+				live.Heartbeat = 10 * time.Millisecond
+				require.NoError(s.ReadGroup(ctx, live))
+
+				claimed := receive(t, ch)
+				require.Equal(msg.ID, claimed.ID)
+				require.Equal(int64(2), claimed.Deliveries)
+				require.Eventually(func() bool {
+					s.mu.Lock()
+					defer s.mu.Unlock()
+					_, ok := s.consumers[live.Group]["c1"]
+					return !ok
+				}, 5*time.Second, 10*time.Millisecond)
+			},
+		},
+		{
+			name: "trim keeps unacknowledged entries",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				for i := 1; i <= 5; i++ {
+					require.NoError(s.Add("s1", &Message{Payload: fmt.Sprintf("p%d", i)}))
+				}
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				args := newTestArgs("c1", map[string]Handler{
+					"s1": func(ctx context.Context, msg Message) error {
+						if msg.Payload == "p4" {
+							return errors.New("boom")
+						}
+						return nil
+					},
+				})
+				require.NoError(s.ReadGroup(ctx, args))
+				require.Eventually(func() bool {
+					return args.Stats().Handled == 5
+				}, 5*time.Second, 10*time.Millisecond)
+
+				n, err := s.Trim("s1", &Retention{MaxLen: 1, AckedOnly: true})
+				require.NoError(err)
+				require.Equal(int64(3), n)
+
+				n, err = s.Trim("s1", &Retention{MaxLen: 1})
+				require.NoError(err)
+				require.Equal(int64(1), n)
+
+				n, err = s.Trim("s1", &Retention{MaxAge: time.Hour})
+				require.NoError(err)
+				require.Zero(n)
+			},
+		},
+		{
+			name: "info reports lag",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+				require.NoError(s.Add("s1", &Message{Payload: "p2"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message, 2)
+				args := newTestArgs("c1", map[string]Handler{
+					"s1": forward(ch, func(msg Message) error {
+						if msg.Payload == "p2" {
+							return errors.New("boom")
+						}
+						return nil
+					}),
+				})
+				require.NoError(s.ReadGroup(ctx, args))
+				receive(t, ch)
+				last := receive(t, ch)
+				require.Eventually(func() bool {
+					return args.Stats().Handled == 2
+				}, 5*time.Second, 10*time.Millisecond)
+
+				infos, err := s.Info("s1", "missing")
+				require.NoError(err)
+				require.Len(infos, 2)
+				require.Equal(int64(2), infos[0].Length)
+				require.Len(infos[0].Groups, 1)
+				g := infos[0].Groups[0]
+				require.Equal(args.Group, g.Name)
+				require.Equal(last.ID, g.LastDeliveredID)
+				require.Equal(int64(1), g.Pending)
+				require.Equal(map[string]int64{"c1": 1}, g.Consumers)
+				require.Zero(infos[1].Length)
+				require.Empty(infos[1].Groups)
+			},
+		},
+		{
+			name: "rewind and replay",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				for i := 1; i <= 3; i++ {
+					require.NoError(s.Add("s1", &Message{Payload: fmt.Sprintf("p%d", i)}))
+				}
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message, 3)
+				args := newTestArgs("c1", map[string]Handler{"s1": forward(ch, nil)})
+				require.NoError(s.ReadGroup(ctx, args))
+				first := receive(t, ch)
+				second := receive(t, ch)
+				receive(t, ch)
+
+				require.NoError(s.SetGroupID("s1", args.Group, first.ID))
+				require.NoError(s.Add("s1", &Message{Payload: "p4"}))
+				require.Equal(second.ID, receive(t, ch).ID)
+				require.Equal("p3", receive(t, ch).Payload)
+				require.Equal("p4", receive(t, ch).Payload)
+
+				var replayed []interface{}
+				stats, err := s.Replay(ctx, "s1", second.ID, "+", func(ctx context.Context, msg Message) error {
+					replayed = append(replayed, msg.Payload)
+					if msg.Payload == "p3" {
+						return errors.New("boom")
+					}
+					return nil
+				})
+				require.NoError(err)
+				require.Equal([]interface{}{"p2", "p3", "p4"}, replayed)
+				require.Equal(&ReplayStats{Replayed: 3, Failed: 1}, stats)
+
+				require.Error(s.SetGroupID("s1", "missing", "0-0"))
+			},
+		},
+		{
+			name: "inbox skips handled events",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				for _, p := range []string{"e1", "e1", "e2"} {
+					require.NoError(s.Add("s1", &Message{Payload: p}))
+				}
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message, 3)
+				args := newTestArgs("c1", map[string]Handler{"s1": forward(ch, nil)})
+				args.Inbox = &Inbox{
+					TTL: time.Minute,
+					EventID: func(msg Message) string {
+						return fmt.Sprint(msg.Payload)
+					},
+				}
+				require.NoError(s.ReadGroup(ctx, args))
+
+				require.Equal("e1", receive(t, ch).Payload)
+				require.Equal("e2", receive(t, ch).Payload)
+				require.Eventually(func() bool {
+					return pendingCount(s, "s1", args.Group) == 0
+				}, 5*time.Second, 10*time.Millisecond)
+				require.Empty(ch)
+
+				handled, err := s.Handled(args.Group, "e2")
+				require.NoError(err)
+				require.True(handled)
+			},
+		},
+		{
+			name: "weighted lanes are not starved",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				for i := 0; i < 3*readCount; i++ {
+					require.NoError(s.Add("bulk", &Message{Payload: "b"}))
+				}
+				for i := 0; i < readCount; i++ {
+					require.NoError(s.Add("live", &Message{Payload: "l"}))
+				}
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message, 4*readCount)
+				args := newTestArgs("c1", map[string]Handler{
+					"bulk": forward(ch, nil),
+					"live": forward(ch, nil),
+				})
+				args.Weights = map[string]int{"live": readCount}
+				require.NoError(s.ReadGroup(ctx, args))
+
+				// live messages come first, along with a single bulk one
+				live := 0
+				for i := 0; i < readCount+1; i++ {
+					if receive(t, ch).Stream == "live" {
+						live++
+					}
+				}
+				require.Equal(readCount, live)
+			},
+		},
+		{
+			name: "delayed message added when due",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				at := time.Now().Add(200 * time.Millisecond)
+				require.NoError(s.AddDelayed("s1", &Message{Payload: "later"}, at))
+				require.NoError(s.AddDelayed("s1", &Message{Payload: "past"}, time.Now().Add(-time.Second)))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message, 2)
+				args := newTestArgs("c1", map[string]Handler{"s1": forward(ch, nil)})
+				require.NoError(s.ReadGroup(ctx, args))
+
+				require.Equal("past", receive(t, ch).Payload)
+				require.Equal("later", receive(t, ch).Payload)
+				require.False(time.Now().Before(at))
+			},
+		},
+		{
+			name: "add many resumes after failures",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				msgs := []*Message{
+					{Key: "k1", Payload: "p1"},
+					{Key: "k3", Payload: make(chan int)},
+					{Key: "k4", Payload: "p4"},
+				}
+
+				res, err := s.AddMany("s1", "b1", msgs)
+				require.Equal(2, res.Added)
+				var failed BulkError
+				require.True(errors.As(err, &failed))
+				require.Len(failed, 1)
+				require.Error(failed[1])
+
+				// a message inserted in between isn't skipped
+				msgs = []*Message{
+					{Key: "k1", Payload: "p1"},
+					{Key: "k2", Payload: "p2"},
+					{Key: "k3", Payload: "p3"},
+					{Key: "k4", Payload: "p4"},
+				}
+				res, err = s.AddMany("s1", "b1", msgs)
+				require.NoError(err)
+				require.Equal(2, res.Added)
+				require.Equal([]int{0, 3}, res.Skipped)
+
+				var payloads []interface{}
+				_, err = s.Replay(context.Background(), "s1", "-", "+", func(ctx context.Context, msg Message) error {
+					payloads = append(payloads, msg.Payload)
+					return nil
+				})
+				require.NoError(err)
+				require.Equal([]interface{}{"p1", "p4", "p2", "p3"}, payloads)
+
+				// done, the next batch starts over
+				res, err = s.AddMany("s1", "b1", msgs)
+				require.NoError(err)
+				require.Equal(4, res.Added)
+				require.Empty(res.Skipped)
+			},
+		},
+		{
+			name: "producer spools while the streamer is down",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				dir, err := ioutil.TempDir("", "spool")
+				require.NoError(err)
+				defer os.RemoveAll(dir)
+
+				var down int32 = 1
+				st := addFunc(func(ctx context.Context, stream string, msg *Message) error {
+					if atomic.LoadInt32(&down) == 1 {
+						return errors.New("down")
+					}
+					return s.AddContext(ctx, stream, msg)
+				})
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				p, err := NewProducer(ctx, st, &ProducerArgs{
+					Breaker:       &Breaker{Threshold: 1, Cooldown: 50 * time.Millisecond},
+					SpoolDir:      dir,
+					FlushInterval: 10 * time.Millisecond,
+				})
+				require.NoError(err)
+
+				require.NoError(p.Add(ctx, "s1", &Message{Payload: "p1"}))
+				require.Equal(ErrCircuitOpen, p.Send(ctx, "s1", &Message{Payload: "lost"}))
+				require.NoError(p.Add(ctx, "s1", &Message{Payload: "p2"}))
+				require.Equal(2, p.Spooled())
+
+				atomic.StoreInt32(&down, 0)
+				require.Eventually(func() bool {
+					return p.Spooled() == 0
+				}, 5*time.Second, 10*time.Millisecond)
+
+				var payloads []interface{}
+				_, err = s.Replay(ctx, "s1", "-", "+", func(ctx context.Context, msg Message) error {
+					payloads = append(payloads, msg.Payload)
+					return nil
+				})
+				require.NoError(err)
+				require.Equal([]interface{}{"p1", "p2"}, payloads)
+			},
+		},
+		{
+			name: "breaker recovers from a probe given up",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				var calls int32
+				st := addFunc(func(ctx context.Context, stream string, msg *Message) error {
+					switch atomic.AddInt32(&calls, 1) {
+					case 1:
+						return errors.New("down")
+					case 2:
+						<-ctx.Done()
+						return ctx.Err()
+					}
+					return s.AddContext(ctx, stream, msg)
+				})
+				ctx := context.Background()
+				p, err := NewProducer(ctx, st, &ProducerArgs{
+					Breaker: &Breaker{Threshold: 1, Cooldown: 10 * time.Millisecond},
+				})
+				require.NoError(err)
+
+				require.Error(p.Send(ctx, "s1", &Message{Payload: "p1"}))
+				require.Equal(ErrCircuitOpen, p.Send(ctx, "s1", &Message{Payload: "p1"}))
+
+				time.Sleep(20 * time.Millisecond)
+				pctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+				defer cancel()
+				require.Error(p.Send(pctx, "s1", &Message{Payload: "p1"}))
+
+				time.Sleep(20 * time.Millisecond)
+				require.NoError(p.Send(ctx, "s1", &Message{Payload: "p1"}))
+				require.NoError(p.Send(ctx, "s1", &Message{Payload: "p2"}))
+			},
+		},
+		{
+			name: "broadcast consumers get every message",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "old"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				ch1, ch2 := make(chan Message, 1), make(chan Message, 1)
+				args1 := newTestArgs("c1", map[string]Handler{"s1": forward(ch1, nil)})
+				args2 := newTestArgs("c2", map[string]Handler{"s1": forward(ch2, nil)})
+				for _, args := range []*StreamArgs{args1, args2} {
+					args.Mode = Broadcast
+					require.NoError(s.ReadGroup(ctx, args))
+				}
+				require.NotEqual(args1.Group, args2.Group)
+
+				require.NoError(s.Add("s1", &Message{Payload: "new"}))
+				require.Equal("new", receive(t, ch1).Payload)
+				require.Equal("new", receive(t, ch2).Payload)
+
+				cancel()
+				require.Eventually(func() bool {
+					infos, err := s.Info("s1")
+					return err == nil && len(infos[0].Groups) == 0
+				}, 5*time.Second, 10*time.Millisecond)
+			},
+		},
+		{
+			name: "handler panic is dead-lettered",
+			run: func(t *testing.T, s *memoryStreamer) {
+				require.NoError(t, s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				args := newTestArgs("c1", map[string]Handler{
+					"s1": func(ctx context.Context, msg Message) error { panic("boom") },
+				})
+				args.Retries = map[string]*RetryPolicy{"s1": {MaxAttempts: 1}}
+				require.NoError(t, s.ReadGroup(ctx, args))
+
+				require.Eventually(t, func() bool {
+					dls, err := s.DeadLetters("s1", 10)
+					return err == nil && len(dls) == 1
+				}, 5*time.Second, 10*time.Millisecond)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, NewMemoryStreamer())
+		})
+	}
+}
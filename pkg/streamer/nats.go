@@ -0,0 +1,918 @@
+package streamer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/nats-io/nats.go"
+
+	"gospiga/pkg/errors"
+	"gospiga/pkg/log"
+)
+
+const (
+	// fetchWait is the max time to wait for messages on each stream.
+	fetchWait = 100 * time.Millisecond
+	// publishWait is the max time to wait for the acks of a chunk of
+	// messages published at once.
+	publishWait = 10 * time.Second
+)
+
+// natsStreamer keeps streams on NATS JetStream. Each stream is a JetStream
+// stream with a single subject, each consumer group a durable pull consumer
+// with explicit acks. Messages not acknowledged within ClaimIdle are
+// delivered again by JetStream, to any consumer of the group.
+//
+// Entry IDs are made of the time and the sequence of the message on the
+// stream, e.g. "1591005600000-42", so that they compare as Redis IDs.
+type natsStreamer struct {
+	js nats.JetStreamContext
+
+	mu sync.Mutex
+	// streams holds the streams already created.
+	streams map[string]bool
+	// inFlight holds the messages delivered and not settled yet, to be
+	// acknowledged by ID.
+	inFlight map[string]*nats.Msg
+	// failures holds the last failure reason of the messages delivered.
+	failures map[string]string
+	// inboxes holds the inbox buckets, keyed by group.
+	inboxes map[string]nats.KeyValue
+	// batches holds the keys of the messages of the batches added.
+	batches nats.KeyValue
+}
+
+// NewNatsStreamer returns an instance of natsStreamer.
+func NewNatsStreamer(nc *nats.Conn) (*natsStreamer, error) {
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+	return &natsStreamer{
+		js:       js,
+		streams:  make(map[string]bool),
+		inFlight: make(map[string]*nats.Msg),
+		failures: make(map[string]string),
+		inboxes:  make(map[string]nats.KeyValue),
+	}, nil
+}
+
+// natsName turns a stream or group name into a valid JetStream name.
+func natsName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// natsID returns the entry ID of the message stored at t with the given
+// sequence.
+func natsID(t time.Time, seq uint64) string {
+	return fmt.Sprintf("%d-%d", t.UnixNano()/int64(time.Millisecond), seq)
+}
+
+// seqOf returns the stream sequence of the given entry ID.
+func seqOf(id string) uint64 {
+	_, seq := splitID(id)
+	if seq < 0 {
+		return 0
+	}
+	return uint64(seq)
+}
+
+func inFlightKey(stream, group, id string) string {
+	return stream + "/" + group + "/" + id
+}
+
+// ensureStream creates the stream if not done yet.
+func (s *natsStreamer) ensureStream(stream string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.streams[stream] {
+		return nil
+	}
+
+	name := natsName(stream)
+	_, err := s.js.StreamInfo(name)
+	if err == nats.ErrStreamNotFound {
+		_, err = s.js.AddStream(&nats.StreamConfig{
+			Name:     name,
+			Subjects: []string{name},
+			Storage:  nats.FileStorage,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	s.streams[stream] = true
+	return nil
+}
+
+// publish sends the data over the stream, returning its sequence.
+func (s *natsStreamer) publish(stream string, data []byte, opts ...nats.PubOpt) (uint64, error) {
+	err := s.ensureStream(stream)
+	if err != nil {
+		return 0, err
+	}
+	ack, err := s.js.Publish(natsName(stream), data, opts...)
+	if err != nil {
+		return 0, err
+	}
+	return ack.Sequence, nil
+}
+
+// entry returns the message stored on the stream with the given sequence,
+// nil if it does not exist.
+func (s *natsStreamer) entry(stream string, seq uint64) (*nats.RawStreamMsg, error) {
+	raw, err := s.js.GetMsg(natsName(stream), seq)
+	if err == nats.ErrMsgNotFound || err == nats.ErrStreamNotFound {
+		return nil, nil
+	}
+	return raw, err
+}
+
+// state returns the state of the stream, nil if it does not exist.
+func (s *natsStreamer) state(stream string) (*nats.StreamState, error) {
+	info, err := s.js.StreamInfo(natsName(stream))
+	if err == nats.ErrStreamNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &info.State, nil
+}
+
+func (s *natsStreamer) Ack(stream, group string, ids ...string) error {
+	for _, id := range ids {
+		m := s.settle(stream, group, id)
+		if m == nil {
+			// not delivered to this consumer, or already settled
+			continue
+		}
+		err := m.AckSync()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *natsStreamer) Add(stream string, msg *Message) error {
+	return s.AddContext(context.Background(), stream, msg)
+}
+
+// AddContext sends the message over the stream, giving up once ctx is done.
+func (s *natsStreamer) AddContext(ctx context.Context, stream string, msg *Message) error {
+	jmsg, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = s.publish(stream, jmsg, nats.Context(ctx))
+	return err
+}
+
+// AddMany adds the messages to the stream, publishing them asynchronously in
+// chunks, as the redis streamer does. The keys of the messages of the batches
+// added are kept in a key-value bucket.
+func (s *natsStreamer) AddMany(stream, batch string, msgs []*Message) (*BulkResult, error) {
+	return addMany(s, stream, batch, msgs)
+}
+
+func (s *natsStreamer) addChunk(stream string, msgs []*Message) []error {
+	errs := make([]error, len(msgs))
+	err := s.ensureStream(stream)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	acks := make([]nats.PubAckFuture, len(msgs))
+	for i, msg := range msgs {
+		jmsg, err := json.Marshal(msg)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		acks[i], errs[i] = s.js.PublishAsync(natsName(stream), jmsg)
+	}
+
+	timeout := time.NewTimer(publishWait)
+	defer timeout.Stop()
+	for i, ack := range acks {
+		if ack == nil {
+			continue
+		}
+		select {
+		case <-ack.Ok():
+		case errs[i] = <-ack.Err():
+		case <-timeout.C:
+			// the ones left might be stored anyway, as when sent again
+			for j := i; j < len(acks); j++ {
+				if acks[j] != nil {
+					errs[j] = nats.ErrTimeout
+				}
+			}
+			return errs
+		}
+	}
+	return errs
+}
+
+// batchBucket returns the bucket holding the keys of the messages of the
+// batches added.
+func (s *natsStreamer) batchBucket() (nats.KeyValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.batches != nil {
+		return s.batches, nil
+	}
+
+	kv, err := s.js.KeyValue("batches")
+	if err == nats.ErrBucketNotFound {
+		kv, err = s.js.CreateKeyValue(&nats.KeyValueConfig{Bucket: "batches"})
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.batches = kv
+	return kv, nil
+}
+
+func (s *natsStreamer) addedKeys(stream, batch string) (map[string]bool, error) {
+	kv, err := s.batchBucket()
+	if err != nil {
+		return nil, err
+	}
+	added := make(map[string]bool)
+	e, err := kv.Get(natsName(stream) + "." + natsName(batch))
+	if err == nats.ErrKeyNotFound {
+		return added, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	err = json.Unmarshal(e.Value(), &keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		added[k] = true
+	}
+	return added, nil
+}
+
+// addKeys records the keys along with the ones recorded already, the batches
+// are not added concurrently.
+func (s *natsStreamer) addKeys(stream, batch string, keys []string) error {
+	kv, err := s.batchBucket()
+	if err != nil {
+		return err
+	}
+	added, err := s.addedKeys(stream, batch)
+	if err != nil {
+		return err
+	}
+	all := make([]string, 0, len(added)+len(keys))
+	for k := range added {
+		all = append(all, k)
+	}
+	for _, k := range keys {
+		if !added[k] {
+			all = append(all, k)
+		}
+	}
+	jkeys, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	_, err = kv.Put(natsName(stream)+"."+natsName(batch), jkeys)
+	return err
+}
+
+func (s *natsStreamer) clearKeys(stream, batch string) error {
+	kv, err := s.batchBucket()
+	if err != nil {
+		return err
+	}
+	err = kv.Delete(natsName(stream) + "." + natsName(batch))
+	if err == nats.ErrKeyNotFound {
+		return nil
+	}
+	return err
+}
+
+// AckAndAdd acknowledges a given message ID from a stream and sends the given
+// message to another stream. The message is sent with an ID derived from the
+// acknowledged one, so that JetStream drops it if sent again within its
+// duplicate window.
+func (s *natsStreamer) AckAndAdd(fromStream, toStream, group, id string, msg *Message) error {
+	return s.AckAndAddContext(context.Background(), fromStream, toStream, group, id, msg)
+}
+
+// AckAndAddContext is like AckAndAdd, giving up once ctx is done.
+func (s *natsStreamer) AckAndAddContext(ctx context.Context, fromStream, toStream, group, id string, msg *Message) error {
+	jmsg, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	_, ok := s.inFlight[inFlightKey(fromStream, group, id)]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	_, err = s.publish(toStream, jmsg, nats.MsgId(inFlightKey(fromStream, group, id)), nats.Context(ctx))
+	if err != nil {
+		return err
+	}
+	return s.Ack(fromStream, group, id)
+}
+
+// ReadGroup reads messages on the streams of the given args as a consumer of
+// the group, handing them to the stream handlers, until ctx is done.
+func (s *natsStreamer) ReadGroup(ctx context.Context, args *StreamArgs) error {
+	streams := args.Streams()
+	start := args.join()
+
+	subs := make(map[string]*nats.Subscription, len(streams))
+	for _, stream := range streams {
+		err := s.ensureStream(stream)
+		if err != nil {
+			return err
+		}
+		err = s.ensureConsumer(stream, args, start)
+		if err != nil {
+			return err
+		}
+		name, durable := natsName(stream), natsName(args.Group)
+		sub, err := s.js.PullSubscribe(name, durable, nats.Bind(name, durable))
+		if err != nil {
+			return err
+		}
+		subs[stream] = sub
+	}
+
+	args.start()
+	go func() {
+		defer func() {
+			args.stop()
+			for _, sub := range subs {
+				sub.Unsubscribe()
+			}
+			if args.Mode == Broadcast {
+				s.leave(args)
+			}
+		}()
+
+		for {
+			if ctx.Err() != nil {
+				log.Debugf("stop reading streams %s", streams)
+				return
+			}
+
+			for _, l := range args.lanes() {
+				for _, stream := range l.streams {
+					msgs, err := subs[stream].Fetch(int(l.count), nats.MaxWait(fetchWait))
+					if err != nil && err != nats.ErrTimeout && ctx.Err() == nil {
+						log.Errorf("error reading stream %q: %s", stream, err)
+					}
+					if len(msgs) > 0 {
+						s.deliver(ctx, args, stream, msgs)
+					}
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// ensureConsumer creates the durable consumer of the group on the stream if
+// not done yet.
+func (s *natsStreamer) ensureConsumer(stream string, args *StreamArgs, start string) error {
+	name, durable := natsName(stream), natsName(args.Group)
+	_, err := s.js.ConsumerInfo(name, durable)
+	if err != nats.ErrConsumerNotFound {
+		return err
+	}
+
+	cfg := &nats.ConsumerConfig{
+		Durable:       durable,
+		AckPolicy:     nats.AckExplicitPolicy,
+		AckWait:       args.ClaimIdle,
+		MaxDeliver:    -1,
+		DeliverPolicy: nats.DeliverAllPolicy,
+	}
+	if start == "$" {
+		cfg.DeliverPolicy = nats.DeliverNewPolicy
+	}
+	_, err = s.js.AddConsumer(name, cfg)
+	return err
+}
+
+// leave deletes the consumer of the group on its streams, along with its
+// pending messages.
+func (s *natsStreamer) leave(args *StreamArgs) {
+	for _, stream := range args.Streams() {
+		err := s.js.DeleteConsumer(natsName(stream), natsName(args.Group))
+		if err != nil {
+			log.Errorf("error deleting group %q on stream %q: %s", args.Group, stream, err)
+		}
+	}
+}
+
+// deliver hands the messages of the given stream to the workers. Messages that
+// have already been delivered too many times, or that cannot be parsed, are
+// moved to the dead-letter stream.
+func (s *natsStreamer) deliver(ctx context.Context, args *StreamArgs, stream string, msgs []*nats.Msg) {
+	log.Debugf("Consumer %q recived %d message(s)", args.Consumer, len(msgs))
+
+	for _, m := range msgs {
+		meta, err := m.Metadata()
+		if err != nil {
+			log.Errorf("error reading metadata of message on stream %q: %s", stream, err)
+			continue
+		}
+		id := natsID(meta.Timestamp, meta.Sequence.Stream)
+		n := int64(meta.NumDelivered)
+
+		key := inFlightKey(stream, args.Group, id)
+		s.mu.Lock()
+		s.inFlight[key] = m
+		// recorded again if the message fails once more
+		reason := s.failures[key]
+		delete(s.failures, key)
+		s.mu.Unlock()
+
+		if args.MaxAttempts > 0 && n > int64(args.MaxAttempts) {
+			if reason == "" {
+				reason = "max attempts exceeded"
+			}
+			err := s.deadLetter(stream, args.Group, id, string(m.Data), reason, n-1)
+			if err != nil {
+				log.Errorf("error dead-lettering message %q: %s", id, err)
+				continue
+			}
+			args.deadLetteredData(m.Data, id, stream, reason)
+			continue
+		}
+
+		msg, err := unmarshalMessage(m.Data, id, stream)
+		if err != nil {
+			log.Errorf(err.Error())
+			// move malformed message out of the way
+			reason := err.Error()
+			err = s.deadLetter(stream, args.Group, id, string(m.Data), reason, n)
+			if err != nil {
+				log.Errorf("error dead-lettering message %q: %s", id, err)
+				continue
+			}
+			args.deadLettered(Message{ID: id, Stream: stream}, reason)
+			continue
+		}
+		msg.Deliveries = n
+
+		dispatch(ctx, args, s, *msg)
+	}
+}
+
+// settle forgets the given message, returning it if it was in flight.
+func (s *natsStreamer) settle(stream, group, id string) *nats.Msg {
+	key := inFlightKey(stream, group, id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.inFlight[key]
+	delete(s.inFlight, key)
+	delete(s.failures, key)
+	return m
+}
+
+// Nack records the reason why a message could not be processed, handing it
+// back to JetStream so that it is delivered again to the group.
+func (s *natsStreamer) Nack(stream, group, id string, reason error) error {
+	key := inFlightKey(stream, group, id)
+	s.mu.Lock()
+	m, ok := s.inFlight[key]
+	if ok {
+		delete(s.inFlight, key)
+		s.failures[key] = reason.Error()
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return m.Nak()
+}
+
+// Retry schedules the given message to be delivered again to the group after
+// delay, reason is recorded as for Nack.
+func (s *natsStreamer) Retry(group string, msg *Message, delay time.Duration, reason error) error {
+	key := inFlightKey(msg.Stream, group, msg.ID)
+	s.mu.Lock()
+	m, ok := s.inFlight[key]
+	if ok {
+		delete(s.inFlight, key)
+		s.failures[key] = reason.Error()
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return m.NakWithDelay(delay)
+}
+
+// DeadLetter acknowledges the given message and moves it to the dead-letter
+// stream of the stream it was read from.
+func (s *natsStreamer) DeadLetter(group string, msg *Message, reason string) error {
+	jmsg, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return s.deadLetter(msg.Stream, group, msg.ID, string(jmsg), reason, msg.Deliveries)
+}
+
+// deadLetter adds the given message to the dead-letter stream and then
+// acknowledges it. The entry is sent with an ID derived from the message, so
+// that JetStream drops it if sent again within its duplicate window.
+func (s *natsStreamer) deadLetter(stream, group, id, strMsg, reason string, attempts int64) error {
+	key := inFlightKey(stream, group, id)
+	s.mu.Lock()
+	m, ok := s.inFlight[key]
+	if !ok {
+		delete(s.failures, key)
+	}
+	s.mu.Unlock()
+	if !ok {
+		// message already acknowledged
+		return nil
+	}
+
+	entry, err := json.Marshal(map[string]string{
+		"message":  strMsg,
+		"stream":   stream,
+		"group":    group,
+		"id":       id,
+		"reason":   reason,
+		"attempts": strconv.FormatInt(attempts, 10),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.publish(DeadLetterStream(stream), entry, nats.MsgId("dlq/"+key))
+	if err != nil {
+		return err
+	}
+
+	s.settle(stream, group, id)
+	return m.AckSync()
+}
+
+// deadLetterEntry turns a message of a dead-letter stream into a stream entry.
+func deadLetterEntry(raw *nats.RawStreamMsg) redis.XMessage {
+	var fields map[string]string
+	json.Unmarshal(raw.Data, &fields)
+	values := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		values[k] = v
+	}
+	return redis.XMessage{ID: natsID(raw.Time, raw.Sequence), Values: values}
+}
+
+// DeadLetters lists up to count messages moved to the dead-letter stream of
+// the given stream, oldest first.
+func (s *natsStreamer) DeadLetters(stream string, count int64) ([]*DeadLetter, error) {
+	dlq := DeadLetterStream(stream)
+	state, err := s.state(dlq)
+	if err != nil {
+		return nil, err
+	}
+	dls := []*DeadLetter{}
+	if state == nil || state.Msgs == 0 {
+		return dls, nil
+	}
+
+	for seq := state.FirstSeq; seq <= state.LastSeq && int64(len(dls)) < count; seq++ {
+		raw, err := s.entry(dlq, seq)
+		if err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			// redriven
+			continue
+		}
+		dls = append(dls, parseDeadLetter(deadLetterEntry(raw)))
+	}
+	return dls, nil
+}
+
+// GetDeadLetter returns the dead-letter entry matching the given ID, nil if it
+// does not exist.
+func (s *natsStreamer) GetDeadLetter(stream, id string) (*DeadLetter, error) {
+	raw, err := s.entry(DeadLetterStream(stream), seqOf(id))
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	return parseDeadLetter(deadLetterEntry(raw)), nil
+}
+
+// Redrive sends the message of the given dead-letter entry back to its
+// original stream and then removes the entry, returning the new message ID.
+func (s *natsStreamer) Redrive(stream, id string) (string, error) {
+	dlq := DeadLetterStream(stream)
+	raw, err := s.entry(dlq, seqOf(id))
+	if err != nil {
+		return "", err
+	}
+	if raw == nil {
+		return "", errors.NotFound(fmt.Errorf("dead letter %q not found on stream %q", id, dlq))
+	}
+	dl := parseDeadLetter(deadLetterEntry(raw))
+
+	seq, err := s.publish(stream, []byte(dl.Message), nats.MsgId("redrive/"+dlq+"/"+id))
+	if err != nil {
+		return "", err
+	}
+	err = s.js.DeleteMsg(natsName(dlq), raw.Sequence)
+	if err != nil {
+		return "", err
+	}
+
+	sent, err := s.entry(stream, seq)
+	if err != nil || sent == nil {
+		return natsID(time.Now(), seq), err
+	}
+	return natsID(sent.Time, seq), nil
+}
+
+// inbox returns the inbox bucket of the group, creating it with the given
+// ttl if needed. A nil bucket means no event handled yet.
+func (s *natsStreamer) inbox(group string, ttl time.Duration) (nats.KeyValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if kv, ok := s.inboxes[group]; ok {
+		return kv, nil
+	}
+
+	bucket := "inbox_" + natsName(group)
+	kv, err := s.js.KeyValue(bucket)
+	if err == nats.ErrBucketNotFound {
+		if ttl == 0 {
+			return nil, nil
+		}
+		kv, err = s.js.CreateKeyValue(&nats.KeyValueConfig{Bucket: bucket, TTL: ttl})
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.inboxes[group] = kv
+	return kv, nil
+}
+
+// Handled reports whether the group has already handled the event.
+func (s *natsStreamer) Handled(group, eventID string) (bool, error) {
+	kv, err := s.inbox(group, 0)
+	if err != nil || kv == nil {
+		return false, err
+	}
+	_, err = kv.Get(natsName(eventID))
+	if err == nats.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// AckHandled records that the group handled the event carried by the message
+// and then acknowledges it. The records expire after the ttl of the first
+// call.
+func (s *natsStreamer) AckHandled(group string, msg *Message, eventID string, ttl time.Duration) error {
+	kv, err := s.inbox(group, ttl)
+	if err != nil {
+		return err
+	}
+	_, err = kv.PutString(natsName(eventID), msg.ID)
+	if err != nil {
+		return err
+	}
+	return s.Ack(msg.Stream, group, msg.ID)
+}
+
+// SetGroupID moves the group on the stream to the given ID: the entries after
+// it are delivered again to the group, the ones before are skipped. The
+// consumer of the group is created again, dropping its pending entries.
+func (s *natsStreamer) SetGroupID(stream, group, id string) error {
+	name, durable := natsName(stream), natsName(group)
+	info, err := s.js.ConsumerInfo(name, durable)
+	if err != nil {
+		return err
+	}
+
+	cfg := info.Config
+	cfg.OptStartSeq, cfg.OptStartTime = 0, nil
+	ms, seq := splitID(id)
+	switch {
+	case id == "$":
+		cfg.DeliverPolicy = nats.DeliverNewPolicy
+	case seq == math.MaxInt64:
+		// ID from a time
+		t := time.Unix(0, (ms+1)*int64(time.Millisecond))
+		cfg.DeliverPolicy = nats.DeliverByStartTimePolicy
+		cfg.OptStartTime = &t
+	default:
+		cfg.DeliverPolicy = nats.DeliverByStartSequencePolicy
+		cfg.OptStartSeq = uint64(seq) + 1
+	}
+
+	err = s.js.DeleteConsumer(name, durable)
+	if err != nil {
+		return err
+	}
+	_, err = s.js.AddConsumer(name, &cfg)
+	return err
+}
+
+// Rewind moves the group on the stream back to the given time: the entries
+// added from then on are delivered again to the group.
+func (s *natsStreamer) Rewind(stream, group string, since time.Time) error {
+	return s.SetGroupID(stream, group, TimeID(since))
+}
+
+// Replay hands the entries of the stream between start and end, both
+// included, to the handler, leaving the groups untouched. Use "-" and "+" for
+// the first and the last entry.
+func (s *natsStreamer) Replay(ctx context.Context, stream, start, end string, h Handler) (*ReplayStats, error) {
+	stats := &ReplayStats{}
+	state, err := s.state(stream)
+	if err != nil || state == nil || state.Msgs == 0 {
+		return stats, err
+	}
+
+	first, last := state.FirstSeq, state.LastSeq
+	if start != "-" && seqOf(start) > first {
+		first = seqOf(start)
+	}
+	if end != "+" && seqOf(end) < last {
+		last = seqOf(end)
+	}
+
+	for seq := first; seq <= last; seq++ {
+		raw, err := s.entry(stream, seq)
+		if err != nil {
+			return stats, err
+		}
+		if raw == nil {
+			continue
+		}
+		rawMsg := redis.XMessage{
+			ID:     natsID(raw.Time, seq),
+			Values: map[string]interface{}{"message": string(raw.Data)},
+		}
+		if !replay(ctx, h, rawMsg, stream, stats) {
+			break
+		}
+	}
+	return stats, ctx.Err()
+}
+
+// Info reports the state of the given streams and of their consumer groups.
+// JetStream does not track the pending entries of each client of a group, nor
+// their idle time.
+func (s *natsStreamer) Info(streams ...string) ([]*StreamInfo, error) {
+	infos := make([]*StreamInfo, 0, len(streams))
+	for _, stream := range streams {
+		info := &StreamInfo{Stream: stream, Groups: []*GroupInfo{}}
+		infos = append(infos, info)
+
+		state, err := s.state(stream)
+		if err != nil {
+			return nil, err
+		}
+		if state == nil {
+			continue
+		}
+		info.Length = int64(state.Msgs)
+
+		for ci := range s.js.Consumers(natsName(stream)) {
+			gi := &GroupInfo{
+				Name:            ci.Name,
+				LastDeliveredID: "0-0",
+				Pending:         int64(ci.NumAckPending),
+				Consumers:       map[string]int64{},
+			}
+			if ci.Delivered.Stream > 0 {
+				last, err := s.entry(stream, ci.Delivered.Stream)
+				if err != nil {
+					return nil, err
+				}
+				if last != nil {
+					gi.LastDeliveredID = natsID(last.Time, last.Sequence)
+				}
+			}
+			info.Groups = append(info.Groups, gi)
+		}
+	}
+	return infos, nil
+}
+
+// firstSince returns the sequence of the oldest entry of the stream stored
+// since t, the one after last if none. JetStream looks it up for an
+// ephemeral consumer starting at t, so that the stream isn't walked.
+func (s *natsStreamer) firstSince(stream string, t time.Time, last uint64) (uint64, error) {
+	name := natsName(stream)
+	sub, err := s.js.PullSubscribe(name, "", nats.BindStream(name), nats.StartTime(t))
+	if err != nil {
+		return 0, err
+	}
+	defer sub.Unsubscribe()
+
+	ci, err := sub.ConsumerInfo()
+	if err != nil {
+		return 0, err
+	}
+	if ci.NumPending == 0 {
+		return last + 1, nil
+	}
+	msgs, err := sub.Fetch(1, nats.MaxWait(fetchWait))
+	if err != nil {
+		return 0, err
+	}
+	meta, err := msgs[0].Metadata()
+	if err != nil {
+		return 0, err
+	}
+	return meta.Sequence.Stream, nil
+}
+
+// Trim applies the retention to the stream, returning the no. of entries
+// reclaimed.
+func (s *natsStreamer) Trim(stream string, r *Retention) (int64, error) {
+	state, err := s.state(stream)
+	if err != nil || state == nil || state.Msgs == 0 {
+		return 0, err
+	}
+
+	// sequence of the oldest entry to keep
+	var cut uint64
+	if r.MaxLen > 0 && state.Msgs > uint64(r.MaxLen) {
+		cut = state.LastSeq - uint64(r.MaxLen) + 1
+	}
+	if r.MaxAge > 0 {
+		seq, err := s.firstSince(stream, time.Now().Add(-r.MaxAge), state.LastSeq)
+		if err != nil {
+			return 0, err
+		}
+		if seq > cut {
+			cut = seq
+		}
+	}
+	if cut <= state.FirstSeq {
+		return 0, nil
+	}
+
+	if r.AckedOnly {
+		var safe uint64
+		for ci := range s.js.Consumers(natsName(stream)) {
+			// throwaway groups and ephemeral consumers don't hold the
+			// stream back
+			if ci.Config.Durable == "" || isReplayGroup(ci.Name) || strings.HasPrefix(ci.Name, natsName(broadcastPrefix)) {
+				continue
+			}
+			if floor := ci.AckFloor.Stream + 1; safe == 0 || floor < safe {
+				safe = floor
+			}
+		}
+		if safe == 0 {
+			return 0, nil
+		}
+		if safe < cut {
+			cut = safe
+		}
+		if cut <= state.FirstSeq {
+			return 0, nil
+		}
+	}
+
+	err = s.js.PurgeStream(natsName(stream), &nats.StreamPurgeRequest{Sequence: cut})
+	if err != nil {
+		return 0, err
+	}
+	after, err := s.state(stream)
+	if err != nil || after == nil {
+		return 0, err
+	}
+	return int64(state.Msgs - after.Msgs), nil
+}
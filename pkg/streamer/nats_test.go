@@ -0,0 +1,340 @@
+package streamer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	errs "gospiga/pkg/errors"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestNats returns a streamer connected to an embedded JetStream server.
+func newTestNats(t *testing.T) *natsStreamer {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "jetstream")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  dir,
+	})
+	require.NoError(t, err)
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	require.True(t, srv.ReadyForConnections(5*time.Second))
+
+	nc, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	s, err := NewNatsStreamer(nc)
+	require.NoError(t, err)
+	return s
+}
+
+func natsPending(s *natsStreamer, stream, group string) int {
+	ci, err := s.js.ConsumerInfo(natsName(stream), natsName(group))
+	if err != nil {
+		return -1
+	}
+	return ci.NumAckPending
+}
+
+func TestNatsStreamer(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s *natsStreamer)
+	}{
+		{
+			name: "read and ack",
+			run: func(t *testing.T, s *natsStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message)
+				args := newTestArgs("c1", map[string]Handler{"s1": forward(ch, nil)})
+				require.NoError(s.ReadGroup(ctx, args))
+
+				msg := receive(t, ch)
+				require.Equal("s1", msg.Stream)
+				require.Equal("p1", msg.Payload)
+				require.Equal(int64(1), msg.Deliveries)
+
+				require.Eventually(func() bool {
+					return natsPending(s, "s1", args.Group) == 0
+				}, 5*time.Second, 10*time.Millisecond)
+			},
+		},
+		{
+			name: "ack and add",
+			run: func(t *testing.T, s *natsStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message)
+				args := newTestArgs("c1", nil)
+				args.Handlers = map[string]Handler{
+					"s1": func(ctx context.Context, msg Message) error {
+						return s.AckAndAdd(msg.Stream, "s2", args.Group, msg.ID, &Message{Payload: "p2"})
+					},
+					"s2": forward(ch, nil),
+				}
+				require.NoError(s.ReadGroup(ctx, args))
+
+				relayed := receive(t, ch)
+				require.Equal("s2", relayed.Stream)
+				require.Equal("p2", relayed.Payload)
+				require.Zero(natsPending(s, "s1", args.Group))
+			},
+		},
+		{
+			name: "dead-letter and redrive",
+			run: func(t *testing.T, s *natsStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message, 1)
+				calls := 0
+				args := newTestArgs("c1", map[string]Handler{
+					"s1": forward(ch, func(Message) error {
+						calls++
+						if calls == 1 {
+							return errs.Permanent(errors.New("boom"))
+						}
+						return nil
+					}),
+				})
+				require.NoError(s.ReadGroup(ctx, args))
+
+				msg := receive(t, ch)
+				var dls []*DeadLetter
+				require.Eventually(func() bool {
+					var err error
+					dls, err = s.DeadLetters("s1", 10)
+					return err == nil && len(dls) == 1
+				}, 5*time.Second, 10*time.Millisecond)
+				require.Equal(msg.ID, dls[0].MessageID)
+				require.Equal("boom", dls[0].Reason)
+				require.Equal(int64(1), dls[0].Attempts)
+
+				dl, err := s.GetDeadLetter("s1", dls[0].ID)
+				require.NoError(err)
+				require.Equal(dls[0], dl)
+
+				_, err = s.Redrive("s1", dls[0].ID)
+				require.NoError(err)
+
+				redriven := receive(t, ch)
+				require.Equal("p1", redriven.Payload)
+				dls, err = s.DeadLetters("s1", 10)
+				require.NoError(err)
+				require.Empty(dls)
+			},
+		},
+		{
+			name: "retry until max attempts",
+			run: func(t *testing.T, s *natsStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message, 2)
+				failing := forward(ch, func(msg Message) error {
+					return fmt.Errorf("boom %d", msg.Deliveries)
+				})
+				args := newTestArgs("c1", map[string]Handler{"s1": failing})
+				args.Retries = map[string]*RetryPolicy{
+					"s1": {MaxAttempts: 2, BaseDelay: time.Millisecond},
+				}
+				require.NoError(s.ReadGroup(ctx, args))
+
+				msg := receive(t, ch)
+				retried := receive(t, ch)
+				require.Equal(msg.ID, retried.ID)
+				require.Equal(int64(2), retried.Deliveries)
+
+				require.Eventually(func() bool {
+					dls, err := s.DeadLetters("s1", 10)
+					return err == nil && len(dls) == 1 && dls[0].Reason == "boom 2"
+				}, 5*time.Second, 10*time.Millisecond)
+			},
+		},
+		{
+			name: "rewind and replay",
+			run: func(t *testing.T, s *natsStreamer) {
+				require := require.New(t)
+				for i := 1; i <= 3; i++ {
+					require.NoError(s.Add("s1", &Message{Payload: fmt.Sprintf("p%d", i)}))
+				}
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message, 3)
+				args := newTestArgs("c1", map[string]Handler{"s1": forward(ch, nil)})
+				require.NoError(s.ReadGroup(ctx, args))
+				first := receive(t, ch)
+				second := receive(t, ch)
+				receive(t, ch)
+
+				require.NoError(s.SetGroupID("s1", args.Group, first.ID))
+				require.NoError(s.Add("s1", &Message{Payload: "p4"}))
+				require.Equal(second.ID, receive(t, ch).ID)
+				require.Equal("p3", receive(t, ch).Payload)
+				require.Equal("p4", receive(t, ch).Payload)
+
+				var replayed []interface{}
+				stats, err := s.Replay(ctx, "s1", second.ID, "+", func(ctx context.Context, msg Message) error {
+					replayed = append(replayed, msg.Payload)
+					if msg.Payload == "p3" {
+						return errors.New("boom")
+					}
+					return nil
+				})
+				require.NoError(err)
+				require.Equal([]interface{}{"p2", "p3", "p4"}, replayed)
+				require.Equal(&ReplayStats{Replayed: 3, Failed: 1}, stats)
+
+				require.Error(s.SetGroupID("s1", "missing", "0-0"))
+			},
+		},
+		{
+			name: "inbox skips handled events",
+			run: func(t *testing.T, s *natsStreamer) {
+				require := require.New(t)
+				for _, p := range []string{"e1", "e1", "e2"} {
+					require.NoError(s.Add("s1", &Message{Payload: p}))
+				}
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message, 3)
+				args := newTestArgs("c1", map[string]Handler{"s1": forward(ch, nil)})
+				args.Inbox = &Inbox{
+					TTL: time.Minute,
+					EventID: func(msg Message) string {
+						return fmt.Sprint(msg.Payload)
+					},
+				}
+				require.NoError(s.ReadGroup(ctx, args))
+
+				require.Equal("e1", receive(t, ch).Payload)
+				require.Equal("e2", receive(t, ch).Payload)
+				require.Eventually(func() bool {
+					return natsPending(s, "s1", args.Group) == 0
+				}, 5*time.Second, 10*time.Millisecond)
+				require.Empty(ch)
+
+				handled, err := s.Handled(args.Group, "e2")
+				require.NoError(err)
+				require.True(handled)
+			},
+		},
+		{
+			name: "nack delivers again before claim idle",
+			run: func(t *testing.T, s *natsStreamer) {
+				require := require.New(t)
+				require.NoError(s.Add("s1", &Message{Payload: "p1"}))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch := make(chan Message, 2)
+				first := true
+				args := newTestArgs("c1", map[string]Handler{"s1": forward(ch, func(Message) error {
+					if first {
+						first = false
+						return errors.New("boom")
+					}
+					return nil
+				})})
+				args.ClaimIdle = time.Minute
+				require.NoError(s.ReadGroup(ctx, args))
+
+				require.Equal(int64(1), receive(t, ch).Deliveries)
+				require.Equal(int64(2), receive(t, ch).Deliveries)
+				require.Eventually(func() bool {
+					return natsPending(s, "s1", args.Group) == 0
+				}, 5*time.Second, 10*time.Millisecond)
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				require.Empty(s.failures)
+			},
+		},
+		{
+			name: "trim by age",
+			run: func(t *testing.T, s *natsStreamer) {
+				require := require.New(t)
+				for i := 1; i <= 3; i++ {
+					require.NoError(s.Add("s1", &Message{Payload: fmt.Sprintf("p%d", i)}))
+				}
+				time.Sleep(100 * time.Millisecond)
+				require.NoError(s.Add("s1", &Message{Payload: "p4"}))
+
+				n, err := s.Trim("s1", &Retention{MaxAge: 50 * time.Millisecond})
+				require.NoError(err)
+				require.Equal(int64(3), n)
+
+				n, err = s.Trim("s1", &Retention{MaxAge: time.Hour})
+				require.NoError(err)
+				require.Zero(n)
+
+				time.Sleep(100 * time.Millisecond)
+				n, err = s.Trim("s1", &Retention{MaxAge: 50 * time.Millisecond})
+				require.NoError(err)
+				require.Equal(int64(1), n)
+
+				cis := 0
+				for range s.js.Consumers(natsName("s1")) {
+					cis++
+				}
+				require.Zero(cis)
+			},
+		},
+		{
+			name: "trim keeps unacknowledged entries",
+			run: func(t *testing.T, s *natsStreamer) {
+				require := require.New(t)
+				for i := 1; i <= 4; i++ {
+					require.NoError(s.Add("s1", &Message{Payload: fmt.Sprintf("p%d", i)}))
+				}
+				require.NoError(s.ensureConsumer("s1", newTestArgs("c1", nil), "0-0"))
+
+				n, err := s.Trim("s1", &Retention{MaxLen: 1, AckedOnly: true})
+				require.NoError(err)
+				require.Zero(n)
+
+				n, err = s.Trim("s1", &Retention{MaxLen: 1})
+				require.NoError(err)
+				require.Equal(int64(3), n)
+
+				infos, err := s.Info("s1")
+				require.NoError(err)
+				require.Equal(int64(1), infos[0].Length)
+				require.Len(infos[0].Groups, 1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newTestNats(t))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package streamer
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats of a consumer, updated while messages are handled.
+type Stats struct {
+	// Started is when the consumer started reading.
+	Started time.Time `json:"started"`
+	// Handled is the no. of messages handed to the handlers so far.
+	Handled int64 `json:"handled"`
+	// Failed is the no. of messages whose handler returned an error.
+	Failed int64 `json:"failed"`
+	// InFlight is the no. of messages read and not handled yet.
+	InFlight int64 `json:"inFlight"`
+}
+
+// Throughput returns the average no. of messages handled per second since the
+// consumer started.
+func (s Stats) Throughput() float64 {
+	elapsed := time.Since(s.Started).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Handled) / elapsed
+}
+
+type consumerStats struct {
+	handled  int64
+	failed   int64
+	inFlight int64
+	started  time.Time
+}
+
+// workerPool runs jobs on a fixed set of workers. Jobs sharing the same key
+// always run on the same worker, in the order they were submitted.
+type workerPool struct {
+	queues []chan func()
+	wg     sync.WaitGroup
+}
+
+func newWorkerPool(workers int) *workerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &workerPool{queues: make([]chan func(), workers)}
+	for i := range p.queues {
+		q := make(chan func(), readCount)
+		p.queues[i] = q
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for job := range q {
+				job()
+			}
+		}()
+	}
+	return p
+}
+
+// run queues job on the worker of the given key, blocking while its queue is
+// full.
+func (p *workerPool) run(key string, job func()) {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	p.queues[h.Sum32()%uint32(len(p.queues))] <- job
+}
+
+// close waits for the queued jobs to be done and stops the workers.
+func (p *workerPool) close() {
+	for _, q := range p.queues {
+		close(q)
+	}
+	p.wg.Wait()
+}
+
+// dispatch hands the message to the worker of its key, to be handled and
+// settled.
+func dispatch(ctx context.Context, args *StreamArgs, s settler, msg Message) {
+	atomic.AddInt64(&args.stats.inFlight, 1)
+	args.pool.run(args.key(msg), func() {
+		defer atomic.AddInt64(&args.stats.inFlight, -1)
+		if ctx.Err() != nil {
+			// leave it pending, it will be delivered again
+			return
+		}
+
+		err := handle(ctx, s, args, msg)
+		atomic.AddInt64(&args.stats.handled, 1)
+		if err != nil {
+			atomic.AddInt64(&args.stats.failed, 1)
+		}
+	})
+}
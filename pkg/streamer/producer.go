@@ -0,0 +1,137 @@
+package streamer
+
+import (
+	"context"
+	"time"
+
+	"gospiga/pkg/log"
+)
+
+// ContextAdder sends messages over streams, giving up once the context is
+// done.
+type ContextAdder interface {
+	AddContext(ctx context.Context, stream string, msg *Message) error
+}
+
+// ProducerArgs configures a producer.
+type ProducerArgs struct {
+	// Timeout of each message sent. Zero means no timeout.
+	Timeout time.Duration
+	// Breaker to fail fast while the streamer is unhealthy. Nil disables it.
+	Breaker *Breaker
+	// SpoolDir is the directory where Add keeps the messages that could not
+	// be sent, until they can. Empty disables the spool.
+	SpoolDir string
+	// FlushInterval between two attempts to send the spooled messages.
+	// Defaults to a second.
+	FlushInterval time.Duration
+}
+
+// producer sends messages over a streamer with deadlines, failing fast while
+// it's unhealthy and spooling the messages meanwhile, if configured.
+type producer struct {
+	st    ContextAdder
+	args  *ProducerArgs
+	spool *spool
+}
+
+// NewProducer returns a producer sending messages over st. The spool, if
+// any, is flushed in the background until ctx is done.
+func NewProducer(ctx context.Context, st ContextAdder, args *ProducerArgs) (*producer, error) {
+	p := &producer{st: st, args: args}
+	if args.SpoolDir == "" {
+		return p, nil
+	}
+
+	sp, err := openSpool(args.SpoolDir)
+	if err != nil {
+		return nil, err
+	}
+	p.spool = sp
+	go p.flushSpool(ctx)
+
+	return p, nil
+}
+
+// Add sends the message over the stream. If the message can't be sent it is
+// spooled, and so are the next ones until the spool is flushed, to keep
+// their order.
+func (p *producer) Add(ctx context.Context, stream string, msg *Message) error {
+	if p.spool == nil {
+		return p.Send(ctx, stream, msg)
+	}
+	if p.spool.len() > 0 {
+		return p.spool.add(stream, msg)
+	}
+
+	err := p.Send(ctx, stream, msg)
+	if err != nil && ctx.Err() == nil {
+		log.Warnf("spooling message for stream %q: %s", stream, err)
+		return p.spool.add(stream, msg)
+	}
+	return err
+}
+
+// Send sends the message over the stream, never spooling it. It's meant for
+// callers keeping the message until it's sent.
+func (p *producer) Send(ctx context.Context, stream string, msg *Message) error {
+	ok, probe := p.args.Breaker.allow()
+	if !ok {
+		return ErrCircuitOpen
+	}
+
+	sctx := ctx
+	if p.args.Timeout > 0 {
+		var cancel context.CancelFunc
+		sctx, cancel = context.WithTimeout(ctx, p.args.Timeout)
+		defer cancel()
+	}
+	err := p.st.AddContext(sctx, stream, msg)
+
+	// the caller giving up says nothing about the streamer
+	switch {
+	case ctx.Err() == nil:
+		p.args.Breaker.record(err)
+	case probe:
+		p.args.Breaker.release()
+	}
+	return err
+}
+
+// Spooled returns the no. of messages waiting in the spool.
+func (p *producer) Spooled() int {
+	if p.spool == nil {
+		return 0
+	}
+	return p.spool.len()
+}
+
+// flushSpool sends the spooled messages every interval until ctx is done.
+func (p *producer) flushSpool(ctx context.Context) {
+	interval := p.args.FlushInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		if p.spool.len() == 0 {
+			continue
+		}
+		// fails fast while the breaker is open
+		n, err := p.spool.flush(ctx, p.Send)
+		if n > 0 {
+			log.Infof("flushed %d spooled message(s)", n)
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Errorf("error flushing spool: %s", err)
+		}
+	}
+}
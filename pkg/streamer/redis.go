@@ -1,12 +1,12 @@
 package streamer
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v7"
@@ -14,47 +14,79 @@ import (
 	"gospiga/pkg/log"
 )
 
-var ackAndAddLua = ""
+// readCount is the max no. of messages read per stream on each read.
+const readCount = 10
+
+var (
+	ackAndAddLua   = ""
+	deadLetterLua  = ""
+	redriveLua     = ""
+	popDueLua      = ""
+	ackInboxLua    = ""
+	moveDelayedLua = ""
+)
 
 type redisStreamer struct {
 	rdb *redis.Client
 }
 
-// StreamArgs required to deal with streams.
-type StreamArgs struct {
-	Streams  []string
-	Group    string
-	Consumer string
-	Messages chan Message
-	Exit     chan struct{}
-	WG       *sync.WaitGroup
-}
-
 // NewRedisStreamer returns an instance of redisStreamer.
 func NewRedisStreamer(client *redis.Client) (*redisStreamer, error) {
-	file, err := os.Open("/scripts/lua/ackAndAdd.lua")
+	var err error
+	ackAndAddLua, err = loadScript(client, "/scripts/lua/ackAndAdd.lua")
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-
-	script, err := ioutil.ReadAll(file)
+	deadLetterLua, err = loadScript(client, "/scripts/lua/deadLetter.lua")
+	if err != nil {
+		return nil, err
+	}
+	redriveLua, err = loadScript(client, "/scripts/lua/redrive.lua")
+	if err != nil {
+		return nil, err
+	}
+	popDueLua, err = loadScript(client, "/scripts/lua/popDue.lua")
+	if err != nil {
+		return nil, err
+	}
+	ackInboxLua, err = loadScript(client, "/scripts/lua/ackInbox.lua")
 	if err != nil {
 		return nil, err
 	}
-	ackAndAddLua, err = client.ScriptLoad(string(script)).Result()
+	moveDelayedLua, err = loadScript(client, "/scripts/lua/moveDelayed.lua")
 	if err != nil {
 		return nil, err
 	}
 	return &redisStreamer{client}, nil
 }
 
+// loadScript reads the lua script at the given path and loads it into redis,
+// returning its SHA1 digest.
+func loadScript(client *redis.Client, path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	script, err := ioutil.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	return client.ScriptLoad(string(script)).Result()
+}
+
 func (s *redisStreamer) Ack(stream, group string, ids ...string) error {
 	_, err := s.rdb.XAck(stream, group, ids...).Result()
 	return err
 }
 
 func (s *redisStreamer) Add(stream string, msg *Message) error {
+	return s.AddContext(context.Background(), stream, msg)
+}
+
+// AddContext sends the message over the stream, giving up once ctx is done.
+func (s *redisStreamer) AddContext(ctx context.Context, stream string, msg *Message) error {
 	jmsg, err := json.Marshal(msg)
 	if err != nil {
 		return err
@@ -64,20 +96,25 @@ func (s *redisStreamer) Add(stream string, msg *Message) error {
 		Stream: stream,
 		Values: map[string]interface{}{"message": string(jmsg)},
 	}
-	_, err = s.rdb.XAdd(xargs).Result()
+	_, err = s.rdb.WithContext(ctx).XAdd(xargs).Result()
 	return err
 }
 
 // AckAndAdd atomically acknowledges a given message ID from a stream and
 // sends the given message to another stream.
 func (s *redisStreamer) AckAndAdd(fromStream, toStream, group, id string, msg *Message) error {
+	return s.AckAndAddContext(context.Background(), fromStream, toStream, group, id, msg)
+}
+
+// AckAndAddContext is like AckAndAdd, giving up once ctx is done.
+func (s *redisStreamer) AckAndAddContext(ctx context.Context, fromStream, toStream, group, id string, msg *Message) error {
 	jmsg, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
 
 	// run pre-loaded script
-	_, err = s.rdb.EvalSha(
+	_, err = s.rdb.WithContext(ctx).EvalSha(
 		ackAndAddLua,
 		[]string{fromStream, toStream},               // KEYS
 		[]string{group, id, "message", string(jmsg)}, // ARGV
@@ -86,59 +123,77 @@ func (s *redisStreamer) AckAndAdd(fromStream, toStream, group, id string, msg *M
 	return err
 }
 
-// ReadGroup reads messages on the given stream and sends them over a channel.
-func (s *redisStreamer) ReadGroup(args *StreamArgs) error {
+// ReadGroup reads messages on the streams of the given args as a consumer of
+// the group, handing them to the stream handlers, until ctx is done.
+func (s *redisStreamer) ReadGroup(ctx context.Context, args *StreamArgs) error {
+	streams := args.Streams()
+	start := args.join()
+
 	// create consumer group if not done yet
-	for _, stream := range args.Streams {
-		_, err := s.rdb.XGroupCreateMkStream(stream, args.Group, "0-0").Result()
+	for _, stream := range streams {
+		_, err := s.rdb.XGroupCreateMkStream(stream, args.Group, start).Result()
 		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
 			return err
 		}
 	}
 
+	if args.Heartbeat > 0 {
+		err := s.heartbeat(args)
+		if err != nil {
+			return err
+		}
+	}
+
+	rdb := s.rdb.WithContext(ctx)
+
+	args.start()
 	go func() {
+		defer func() {
+			args.stop()
+			if args.Mode == Broadcast {
+				s.leave(args)
+			}
+		}()
+
 		checkHistory := true
+		var lastClaim time.Time
+		lastBeat := time.Now()
 
-		lastIDs := make(map[string]string, len(args.Streams))
-		for _, stream := range args.Streams {
+		lastIDs := make(map[string]string, len(streams))
+		for _, stream := range streams {
 			lastIDs[stream] = "0-0"
 		}
 		for {
-			if !checkHistory {
-				for _, stream := range args.Streams {
-					lastIDs[stream] = ">"
-				}
+			if ctx.Err() != nil {
+				log.Debugf("stop reading streams %s", streams)
+				return
 			}
 
-			streams := make([]string, 0, len(args.Streams)*2)
-			ids := make([]string, 0, len(args.Streams))
-			for _, id := range lastIDs {
-				ids = append(ids, id)
+			if args.Heartbeat > 0 && time.Since(lastBeat) >= args.Heartbeat {
+				lastBeat = time.Now()
+				err := s.heartbeat(args)
+				if err != nil {
+					log.Errorf("error sending heartbeat of consumer %q: %s", args.Consumer, err)
+				}
+				s.cleanupDead(ctx, args)
 			}
-			streams = append(streams, args.Streams...)
-			streams = append(streams, ids...)
-
-			xargs := &redis.XReadGroupArgs{
-				Group:    args.Group,
-				Consumer: args.Consumer,
-				// List of streams and ids.
-				Streams: streams,
-				// Max no. of elements per stream fo each call.
-				Count: 10,
-				Block: time.Millisecond * 2000,
-				// NoAck   bool
+			if args.ClaimIdle > 0 && time.Since(lastClaim) >= args.claimInterval() {
+				lastClaim = time.Now()
+				s.claimStale(ctx, args)
 			}
+			s.redeliverDue(ctx, args)
+			s.moveDelayed(args)
 
-			// TODO: use WithContext ?
-			res, err := s.rdb.XReadGroup(xargs).Result()
-			if err != nil {
-				if err != redis.Nil {
-					log.Errorf("error reading streams %s: %s", args.Streams, err)
+			if !checkHistory {
+				for _, stream := range streams {
+					lastIDs[stream] = ">"
 				}
-				// Timeout, check if it's time to exit
-				if s.shouldExit(args.Exit) {
-					log.Debugf("stop reading streams %s", args.Streams)
-					return
+			}
+
+			res, err := s.read(rdb, args, lastIDs)
+			if err != nil {
+				if err != redis.Nil && ctx.Err() == nil {
+					log.Errorf("error reading streams %s: %s", streams, err)
 				}
 				continue
 			}
@@ -146,7 +201,7 @@ func (s *redisStreamer) ReadGroup(args *StreamArgs) error {
 			// check if we are up to date
 			if len(res) == 0 {
 				if checkHistory {
-					log.Debugf("done reading history on streams: %v", args.Streams)
+					log.Debugf("done reading history on streams: %v", streams)
 					checkHistory = false
 				}
 				continue
@@ -163,46 +218,157 @@ func (s *redisStreamer) ReadGroup(args *StreamArgs) error {
 					log.Debugf("found pending messages on stream %q, resuming..", stream.Stream)
 				}
 
-				args.WG.Add(msgs)
-
-				log.Debugf("Consumer %q recived %d message(s)", args.Consumer, msgs)
+				lastIDs[stream.Stream] = stream.Messages[msgs-1].ID
 
-				for _, rawMsg := range stream.Messages {
-					log.Debugf("Consumer %q reading message %q", args.Consumer, rawMsg.ID)
+				// messages read from history may have been delivered already
+				s.deliver(ctx, args, stream, checkHistory)
+			}
 
-					lastIDs[stream.Stream] = rawMsg.ID
+			if !gotMessage && checkHistory {
+				log.Debugf("Done reading history on streams: %v", streams)
+				checkHistory = false
+			}
+		}
+	}()
+	return nil
+}
 
-					msg, err := parseMessage(rawMsg, stream.Stream)
-					if err != nil {
-						log.Errorf(err.Error())
-						// clear malformed message
-						s.Ack(stream.Stream, args.Group, rawMsg.ID)
-						continue
-					}
+// leave destroys the group of the consumer on its streams, along with its
+// pending messages.
+func (s *redisStreamer) leave(args *StreamArgs) {
+	for _, stream := range args.Streams() {
+		err := s.rdb.XGroupDestroy(stream, args.Group).Err()
+		if err != nil {
+			log.Errorf("error destroying group %q on stream %q: %s", args.Group, stream, err)
+		}
+		s.rdb.Del(retryKey(stream, args.Group), failuresKey(stream, args.Group))
+	}
+	s.rdb.Del(consumersKey(args.Group))
+}
 
-					args.Messages <- *msg
+// read reads the next messages of the streams after the given IDs, giving
+// each lane its share of the read. It blocks only if no lane has messages.
+func (s *redisStreamer) read(rdb *redis.Client, args *StreamArgs, lastIDs map[string]string) ([]redis.XStream, error) {
+	lanes := args.lanes()
+	if len(lanes) > 1 {
+		var res []redis.XStream
+		for _, l := range lanes {
+			r, err := rdb.XReadGroup(readArgs(args, l.streams, lastIDs, l.count, -1)).Result()
+			if err != nil && err != redis.Nil {
+				return nil, err
+			}
+			for _, stream := range r {
+				if len(stream.Messages) > 0 {
+					res = append(res, stream)
 				}
+			}
+		}
+		if len(res) > 0 {
+			return res, nil
+		}
+	}
+
+	// wait for messages on any stream
+	return rdb.XReadGroup(readArgs(args, args.Streams(), lastIDs, readCount, time.Millisecond*2000)).Result()
+}
 
-				// avoid back-pressure
-				args.WG.Wait()
+func readArgs(args *StreamArgs, streams []string, lastIDs map[string]string, count int64, block time.Duration) *redis.XReadGroupArgs {
+	xstreams := make([]string, 0, len(streams)*2)
+	xstreams = append(xstreams, streams...)
+	for _, stream := range streams {
+		xstreams = append(xstreams, lastIDs[stream])
+	}
+
+	return &redis.XReadGroupArgs{
+		Group:    args.Group,
+		Consumer: args.Consumer,
+		// List of streams and ids.
+		Streams: xstreams,
+		// Max no. of elements per stream fo each call.
+		Count: count,
+		Block: block,
+	}
+}
+
+// deliver hands the messages of the given stream to the workers. Messages that
+// have already been delivered too many times, or that cannot be parsed, are
+// moved to the dead-letter stream.
+func (s *redisStreamer) deliver(ctx context.Context, args *StreamArgs, stream redis.XStream, redelivered bool) {
+	log.Debugf("Consumer %q recived %d message(s)", args.Consumer, len(stream.Messages))
+
+	var deliveries map[string]int64
+	if redelivered {
+		var err error
+		deliveries, err = s.deliveries(stream, args.Group)
+		if err != nil {
+			log.Errorf("error reading delivery counts on stream %q: %s", stream.Stream, err)
+		}
+	}
+
+	for _, rawMsg := range stream.Messages {
+		log.Debugf("Consumer %q reading message %q", args.Consumer, rawMsg.ID)
+
+		n := int64(1)
+		if c, ok := deliveries[rawMsg.ID]; ok {
+			n = c
+		}
+
+		if args.MaxAttempts > 0 && n > int64(args.MaxAttempts) {
+			reason, _ := s.rdb.HGet(failuresKey(stream.Stream, args.Group), rawMsg.ID).Result()
+			if reason == "" {
+				reason = "max attempts exceeded"
+			}
+			err := s.deadLetter(stream.Stream, args.Group, rawMsg, reason, n-1)
+			if err != nil {
+				log.Errorf("error dead-lettering message %q: %s", rawMsg.ID, err)
+				continue
 			}
+			strMsg, _ := rawMsg.Values["message"].(string)
+			args.deadLetteredData([]byte(strMsg), rawMsg.ID, stream.Stream, reason)
+			continue
+		}
 
-			if !gotMessage && checkHistory {
-				log.Debugf("Done reading history on streams: %v", args.Streams)
-				checkHistory = false
+		msg, err := parseMessage(rawMsg, stream.Stream)
+		if err != nil {
+			log.Errorf(err.Error())
+			// move malformed message out of the way
+			reason := err.Error()
+			err = s.deadLetter(stream.Stream, args.Group, rawMsg, reason, n)
+			if err != nil {
+				log.Errorf("error dead-lettering message %q: %s", rawMsg.ID, err)
+				continue
 			}
+			args.deadLettered(Message{ID: rawMsg.ID, Stream: stream.Stream}, reason)
+			continue
 		}
-	}()
-	return nil
+		msg.Deliveries = n
+
+		dispatch(ctx, args, s, *msg)
+	}
 }
 
-func (s *redisStreamer) shouldExit(exitCh chan struct{}) bool {
-	select {
-	case _, ok := <-exitCh:
-		return !ok
-	default:
+// deliveries returns the delivery count of the messages in the given stream
+// batch, keyed by message ID.
+func (s *redisStreamer) deliveries(stream redis.XStream, group string) (map[string]int64, error) {
+	first := stream.Messages[0].ID
+	last := stream.Messages[len(stream.Messages)-1].ID
+
+	pending, err := s.rdb.XPendingExt(&redis.XPendingExtArgs{
+		Stream: stream.Stream,
+		Group:  group,
+		Start:  first,
+		End:    last,
+		Count:  int64(len(stream.Messages)),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(pending))
+	for _, p := range pending {
+		counts[p.ID] = p.RetryCount
 	}
-	return false
+	return counts, nil
 }
 
 func parseMessage(rawMsg redis.XMessage, stream string) (*Message, error) {
@@ -210,13 +376,23 @@ func parseMessage(rawMsg redis.XMessage, stream string) (*Message, error) {
 	if !ok {
 		return nil, fmt.Errorf("cannot parse stream message %q", rawMsg.ID)
 	}
+	return unmarshalMessage([]byte(strMsg), rawMsg.ID, stream)
+}
 
+// unmarshalMessage decodes the message stored with the given ID on the
+// stream.
+func unmarshalMessage(data []byte, id, stream string) (*Message, error) {
 	var msg Message
-	err := json.Unmarshal([]byte(strMsg), &msg)
+	err := json.Unmarshal(data, &msg)
 	if err != nil {
-		return nil, fmt.Errorf("malformed stream message %q, cannot unmarshal to mq.Message", rawMsg.ID)
+		return nil, fmt.Errorf("malformed stream message %q, cannot unmarshal to mq.Message", id)
+	}
+	var raw struct {
+		Payload json.RawMessage `json:"payload"`
 	}
-	msg.ID = rawMsg.ID
+	json.Unmarshal(data, &raw)
+	msg.RawPayload = raw.Payload
+	msg.ID = id
 	msg.Stream = stream
 
 	return &msg, nil
@@ -0,0 +1,139 @@
+package streamer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"gospiga/pkg/log"
+)
+
+// ReplayStats reports the outcome of a replay.
+type ReplayStats struct {
+	// Replayed is the no. of entries handed to the handler.
+	Replayed int64 `json:"replayed"`
+	// Failed is the no. of entries whose handler returned an error.
+	Failed int64 `json:"failed"`
+}
+
+// TimeID returns the ID right before the entries added at t, so that
+// delivering the entries after it starts from t.
+func TimeID(t time.Time) string {
+	ms := t.UnixNano() / int64(time.Millisecond)
+	if ms <= 0 {
+		return "0-0"
+	}
+	return fmt.Sprintf("%d-%d", ms-1, int64(math.MaxInt64))
+}
+
+// prevID returns the greatest ID smaller than id.
+func prevID(id string) string {
+	ms, seq := splitID(id)
+	switch {
+	case seq > 0:
+		return fmt.Sprintf("%d-%d", ms, seq-1)
+	case ms > 0:
+		return fmt.Sprintf("%d-%d", ms-1, int64(math.MaxInt64))
+	}
+	return "0-0"
+}
+
+// replayGroup returns the name of a throwaway group to replay the stream.
+func replayGroup() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return "replay-" + hex.EncodeToString(b)
+}
+
+// SetGroupID moves the group on the stream to the given ID: the entries after
+// it are delivered again to the group, the ones before are skipped. Pending
+// entries are left untouched.
+func (s *redisStreamer) SetGroupID(stream, group, id string) error {
+	return s.rdb.XGroupSetID(stream, group, id).Err()
+}
+
+// Rewind moves the group on the stream back to the given time: the entries
+// added from then on are delivered again to the group.
+func (s *redisStreamer) Rewind(stream, group string, since time.Time) error {
+	return s.SetGroupID(stream, group, TimeID(since))
+}
+
+// Replay hands the entries of the stream between start and end, both
+// included, to the handler. The entries are read by a throwaway group, which
+// is destroyed when done, leaving the other groups untouched. Use "-" and "+"
+// for the first and the last entry.
+func (s *redisStreamer) Replay(ctx context.Context, stream, start, end string, h Handler) (*ReplayStats, error) {
+	if start == "-" {
+		start = "0-0"
+	} else {
+		start = prevID(start)
+	}
+
+	group := replayGroup()
+	err := s.rdb.XGroupCreate(stream, group, start).Err()
+	if err != nil {
+		return nil, err
+	}
+	defer s.rdb.XGroupDestroy(stream, group)
+
+	stats := &ReplayStats{}
+	for ctx.Err() == nil {
+		res, err := s.rdb.WithContext(ctx).XReadGroup(&redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: group,
+			Streams:  []string{stream, ">"},
+			Count:    readCount,
+			Block:    -1,
+			NoAck:    true,
+		}).Result()
+		if err == redis.Nil {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, err
+		}
+		if len(res) == 0 || len(res[0].Messages) == 0 {
+			return stats, nil
+		}
+
+		for _, rawMsg := range res[0].Messages {
+			if end != "+" && CompareIDs(rawMsg.ID, end) > 0 {
+				return stats, nil
+			}
+			if !replay(ctx, h, rawMsg, stream, stats) {
+				return stats, ctx.Err()
+			}
+		}
+	}
+	return stats, ctx.Err()
+}
+
+// replay hands a single entry to the handler, reporting whether to go on.
+func replay(ctx context.Context, h Handler, rawMsg redis.XMessage, stream string, stats *ReplayStats) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	stats.Replayed++
+
+	msg, err := parseMessage(rawMsg, stream)
+	if err == nil {
+		msg.Deliveries = 1
+		err = runHandler(ctx, h, *msg)
+	}
+	if err != nil {
+		stats.Failed++
+		log.Warnf("error replaying message %q on stream %q: %s", rawMsg.ID, stream, err)
+	}
+	return true
+}
+
+// isReplayGroup reports whether the group is a throwaway replay group.
+func isReplayGroup(group string) bool {
+	return strings.HasPrefix(group, "replay-")
+}
@@ -0,0 +1,175 @@
+package streamer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"gospiga/pkg/log"
+)
+
+// Retention of the entries of a stream. Entries beyond any of the limits are
+// trimmed, oldest first.
+type Retention struct {
+	// MaxLen is the no. of entries to keep. Zero means no limit.
+	MaxLen int64
+	// MaxAge of the entries to keep. Zero means no limit.
+	MaxAge time.Duration
+	// AckedOnly keeps the entries not yet acknowledged by every consumer
+	// group of the stream, whatever the limits. Groups created later on only
+	// see the entries left, broadcast groups are not waited for.
+	AckedOnly bool
+}
+
+// Trimmer trims streams according to their retention.
+type Trimmer interface {
+	// Trim applies the retention to the stream, returning the no. of entries
+	// reclaimed.
+	Trim(stream string, r *Retention) (int64, error)
+}
+
+// RunTrimmer trims the given streams every interval until ctx is done,
+// reporting the entries reclaimed.
+func RunTrimmer(ctx context.Context, t Trimmer, retention map[string]*Retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for stream, r := range retention {
+			n, err := t.Trim(stream, r)
+			if err != nil {
+				log.Errorf("error trimming stream %q: %s", stream, err)
+				continue
+			}
+			if n > 0 {
+				log.Infof("trimmed %d entries from stream %q", n, stream)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// cutoff returns the ID of the oldest entry to keep according to the limits,
+// given the ID of the oldest entry within MaxLen, if any. An empty ID means
+// nothing to trim.
+func (r *Retention) cutoff(now time.Time, lenCut string) string {
+	var cut string
+	if r.MaxAge > 0 {
+		cut = fmt.Sprintf("%d-0", now.Add(-r.MaxAge).UnixNano()/int64(time.Millisecond))
+	}
+	if lenCut != "" && (cut == "" || CompareIDs(lenCut, cut) > 0) {
+		cut = lenCut
+	}
+	return cut
+}
+
+// nextID returns the smallest ID greater than id.
+func nextID(id string) string {
+	ms, seq := splitID(id)
+	return fmt.Sprintf("%d-%d", ms, seq+1)
+}
+
+// Trim applies the retention to the stream, returning the no. of entries
+// reclaimed.
+func (s *redisStreamer) Trim(stream string, r *Retention) (int64, error) {
+	var lenCut string
+	if r.MaxLen > 0 {
+		kept, err := s.rdb.XRevRangeN(stream, "+", "-", r.MaxLen).Result()
+		if err != nil {
+			return 0, err
+		}
+		if int64(len(kept)) == r.MaxLen {
+			lenCut = kept[len(kept)-1].ID
+		}
+	}
+
+	cut := r.cutoff(time.Now(), lenCut)
+	if cut == "" {
+		return 0, nil
+	}
+
+	if r.AckedOnly {
+		safe, err := s.ackedUpTo(stream)
+		if err != nil {
+			return 0, err
+		}
+		if safe == "" {
+			return 0, nil
+		}
+		if CompareIDs(safe, cut) < 0 {
+			cut = safe
+		}
+	}
+
+	return s.deleteBefore(stream, cut)
+}
+
+// trimCount is the max no. of entries deleted on each XDEL call.
+const trimCount = 100
+
+// deleteBefore deletes the entries of the stream older than cut, returning
+// their no. XRANGE and XDEL are used in place of XTRIM MINID, which needs
+// Redis 6.2.
+func (s *redisStreamer) deleteBefore(stream, cut string) (int64, error) {
+	var deleted int64
+	for {
+		msgs, err := s.rdb.XRangeN(stream, "-", cut, trimCount).Result()
+		if err != nil {
+			return deleted, err
+		}
+		ids := make([]string, 0, len(msgs))
+		for _, m := range msgs {
+			if CompareIDs(m.ID, cut) < 0 {
+				ids = append(ids, m.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return deleted, nil
+		}
+		n, err := s.rdb.XDel(stream, ids...).Result()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+		if len(ids) < trimCount {
+			return deleted, nil
+		}
+	}
+}
+
+// ackedUpTo returns the ID before which every entry of the stream has been
+// acknowledged by all the groups, or an empty ID if there are no groups.
+func (s *redisStreamer) ackedUpTo(stream string) (string, error) {
+	groups, err := s.rdb.XInfoGroups(stream).Result()
+	if err != nil {
+		return "", err
+	}
+
+	var safe string
+	for _, g := range groups {
+		// throwaway groups don't hold the stream back
+		if isReplayGroup(g.Name) || isBroadcastGroup(g.Name) {
+			continue
+		}
+		id := nextID(g.LastDeliveredID)
+		if g.Pending > 0 {
+			p, err := s.rdb.XPending(stream, g.Name).Result()
+			if err != nil && err != redis.Nil {
+				return "", err
+			}
+			if p != nil && p.Count > 0 {
+				id = p.Lower
+			}
+		}
+		if safe == "" || CompareIDs(id, safe) < 0 {
+			safe = id
+		}
+	}
+	return safe, nil
+}
@@ -0,0 +1,129 @@
+package streamer
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"gospiga/pkg/errors"
+	"gospiga/pkg/log"
+)
+
+// dueCount is the max no. of retries redelivered at once on each stream.
+const dueCount = 10
+
+// RetryPolicy defines how messages that failed to be processed are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts after which a message is not
+	// retried anymore.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled on each attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts.
+	MaxDelay time.Duration
+	// Jitter is the fraction of the delay to randomize, between 0 and 1.
+	Jitter float64
+	// Retryable reports whether an error is worth retrying. By default any
+	// error not marked as permanent is.
+	Retryable func(error) bool
+}
+
+// ShouldRetry reports whether a message that failed with err on the given
+// attempt should be retried.
+func (p *RetryPolicy) ShouldRetry(err error, attempt int) bool {
+	if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return !errors.IsPermanent(err)
+}
+
+// Backoff returns the delay to wait before retrying after the given attempt.
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay == 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// retryKey returns the key of the sorted set scheduling the retries of the
+// messages pending on the given stream and group.
+func retryKey(stream, group string) string {
+	return fmt.Sprintf("%s:retry:%s", stream, group)
+}
+
+// Retry schedules the given message to be delivered again to the group after
+// delay. The message stays pending meanwhile, reason is recorded as for Nack.
+func (s *redisStreamer) Retry(group string, msg *Message, delay time.Duration, reason error) error {
+	due := time.Now().Add(delay)
+	_, err := s.rdb.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(failuresKey(msg.Stream, group), msg.ID, reason.Error())
+		pipe.ZAdd(retryKey(msg.Stream, group), &redis.Z{
+			Score:  float64(due.UnixNano() / int64(time.Millisecond)),
+			Member: msg.ID,
+		})
+		return nil
+	})
+	return err
+}
+
+// redeliverDue claims back the messages whose retry is due and delivers them.
+func (s *redisStreamer) redeliverDue(ctx context.Context, args *StreamArgs) {
+	now := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+
+	for _, stream := range args.Streams() {
+		// run pre-loaded script
+		res, err := s.rdb.EvalSha(
+			popDueLua,
+			[]string{retryKey(stream, args.Group)}, // KEYS
+			[]string{now, strconv.Itoa(dueCount)},  // ARGV
+		).Result()
+		if err != nil {
+			log.Errorf("error reading due retries on stream %q: %s", stream, err)
+			continue
+		}
+
+		rawIDs, _ := res.([]interface{})
+		if len(rawIDs) == 0 {
+			continue
+		}
+		ids := make([]string, 0, len(rawIDs))
+		for _, id := range rawIDs {
+			if sid, ok := id.(string); ok {
+				ids = append(ids, sid)
+			}
+		}
+
+		// messages acknowledged meanwhile are not claimed
+		msgs, err := s.rdb.XClaim(&redis.XClaimArgs{
+			Stream:   stream,
+			Group:    args.Group,
+			Consumer: args.Consumer,
+			Messages: ids,
+		}).Result()
+		if err != nil {
+			log.Errorf("error claiming due retries on stream %q: %s", stream, err)
+			continue
+		}
+		if len(msgs) > 0 {
+			log.Debugf("Consumer %q retrying %d message(s) on stream %q", args.Consumer, len(msgs), stream)
+			s.deliver(ctx, args, redis.XStream{Stream: stream, Messages: msgs}, true)
+		}
+	}
+}
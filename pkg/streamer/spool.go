@@ -0,0 +1,181 @@
+package streamer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// spoolFile is the name of the spool file within its directory.
+const spoolFile = "spool.jsonl"
+
+// spooled is a message kept in the spool, one per line.
+type spooled struct {
+	Stream  string   `json:"stream"`
+	Message *Message `json:"message"`
+}
+
+// spool keeps on disk the messages that could not be added, in order.
+type spool struct {
+	mu sync.Mutex
+	// flushMu serializes the flushes, which send without holding mu.
+	flushMu sync.Mutex
+	path    string
+	// n is the no. of messages in the spool.
+	n int
+}
+
+// openSpool opens the spool in dir, creating dir if needed, with the
+// messages left by a previous run.
+func openSpool(dir string) (*spool, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	sp := &spool{path: filepath.Join(dir, spoolFile)}
+	entries, err := sp.read()
+	if err != nil {
+		return nil, err
+	}
+	sp.n = len(entries)
+	return sp, nil
+}
+
+// len returns the no. of messages in the spool.
+func (sp *spool) len() int {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return sp.n
+}
+
+// add appends the message to the spool, syncing it to disk.
+func (sp *spool) add(stream string, msg *Message) error {
+	line, err := json.Marshal(spooled{Stream: stream, Message: msg})
+	if err != nil {
+		return err
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	f, err := os.OpenFile(sp.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if err != nil {
+		return err
+	}
+	sp.n++
+	return nil
+}
+
+// read returns the messages in the spool, oldest first.
+func (sp *spool) read() ([]spooled, error) {
+	f, err := os.Open(sp.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []spooled
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 16*1024*1024)
+	for sc.Scan() {
+		var e spooled
+		if json.Unmarshal(sc.Bytes(), &e) != nil {
+			// torn write
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// flush adds the spooled messages in order, stopping at the first failure.
+// The messages added are removed from the spool. The messages are sent
+// without holding the lock, so that they can still be spooled meanwhile.
+func (sp *spool) flush(ctx context.Context, add func(context.Context, string, *Message) error) (int, error) {
+	sp.flushMu.Lock()
+	defer sp.flushMu.Unlock()
+
+	sp.mu.Lock()
+	entries, err := sp.read()
+	sp.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	var done int
+	for _, e := range entries {
+		err = add(ctx, e.Stream, e.Message)
+		if err != nil {
+			break
+		}
+		done++
+	}
+	if done == 0 {
+		return 0, err
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	// messages may have been spooled since, after the ones sent
+	entries, rerr := sp.read()
+	if rerr == nil {
+		rerr = sp.rewrite(entries[done:])
+	}
+	if rerr != nil {
+		return done, rerr
+	}
+	return done, err
+}
+
+// rewrite replaces the content of the spool with the given messages. Must be
+// called with the lock held.
+func (sp *spool) rewrite(entries []spooled) error {
+	sp.n = len(entries)
+	if len(entries) == 0 {
+		return os.Remove(sp.path)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(sp.path), spoolFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		w.Write(append(line, '\n'))
+	}
+	err = w.Flush()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	cerr := tmp.Close()
+	if err != nil {
+		return err
+	}
+	if cerr != nil {
+		return cerr
+	}
+	return os.Rename(tmp.Name(), sp.path)
+}
@@ -1,7 +1,18 @@
 package streamer
 
+import "encoding/json"
+
 type Message struct {
 	ID      string      `json:"id"`
 	Stream  string      `json:"stream"`
 	Payload interface{} `json:"payload"`
+	// Key identifies the message within the batches of AddMany, it's not
+	// sent along.
+	Key string `json:"-"`
+	// Deliveries is the number of times the message has been delivered to the
+	// consumer group, this delivery included.
+	Deliveries int64 `json:"-"`
+	// RawPayload is the payload as read from the stream, to be decoded into
+	// a typed value.
+	RawPayload json.RawMessage `json:"-"`
 }
@@ -0,0 +1,20 @@
+package types
+
+import (
+	"time"
+)
+
+type Job struct {
+	ID         string     `json:"id"`
+	State      string     `json:"state"`
+	Total      int        `json:"total"`
+	Enqueued   int        `json:"enqueued"`
+	Pending    int        `json:"pending"`
+	Saved      int        `json:"saved"`
+	Failed     int        `json:"failed"`
+	Skipped    int        `json:"skipped"`
+	Cancelled  int        `json:"cancelled"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
@@ -0,0 +1,13 @@
+package types
+
+type Reconciliation struct {
+	DryRun bool `json:"dryRun"`
+	// New are the recipes missing from the store.
+	New []string `json:"new"`
+	// Deleted are the recipes stored and gone from the provider.
+	Deleted []string `json:"deleted"`
+	// Updated are the recipes updated on the provider after being stored.
+	Updated []string `json:"updated"`
+	// Failed are the recipes whose event could not be sent.
+	Failed []string `json:"failed"`
+}
@@ -0,0 +1,664 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: events.proto
+
+package proto
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type EventMeta struct {
+	Version              int32                `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Id                   string               `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Timestamp            *timestamp.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Source               string               `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *EventMeta) Reset()         { *m = EventMeta{} }
+func (m *EventMeta) String() string { return proto.CompactTextString(m) }
+func (*EventMeta) ProtoMessage()    {}
+func (*EventMeta) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f22242cb04491f9, []int{0}
+}
+
+func (m *EventMeta) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EventMeta.Unmarshal(m, b)
+}
+func (m *EventMeta) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EventMeta.Marshal(b, m, deterministic)
+}
+func (m *EventMeta) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EventMeta.Merge(m, src)
+}
+func (m *EventMeta) XXX_Size() int {
+	return xxx_messageInfo_EventMeta.Size(m)
+}
+func (m *EventMeta) XXX_DiscardUnknown() {
+	xxx_messageInfo_EventMeta.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EventMeta proto.InternalMessageInfo
+
+func (m *EventMeta) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *EventMeta) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *EventMeta) GetTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
+func (m *EventMeta) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+type RecipeCreated struct {
+	Meta                 *EventMeta `protobuf:"bytes,1,opt,name=meta,proto3" json:"meta,omitempty"`
+	RecipeId             string     `protobuf:"bytes,2,opt,name=recipe_id,json=recipeId,proto3" json:"recipe_id,omitempty"`
+	JobId                string     `protobuf:"bytes,3,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *RecipeCreated) Reset()         { *m = RecipeCreated{} }
+func (m *RecipeCreated) String() string { return proto.CompactTextString(m) }
+func (*RecipeCreated) ProtoMessage()    {}
+func (*RecipeCreated) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f22242cb04491f9, []int{1}
+}
+
+func (m *RecipeCreated) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecipeCreated.Unmarshal(m, b)
+}
+func (m *RecipeCreated) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecipeCreated.Marshal(b, m, deterministic)
+}
+func (m *RecipeCreated) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecipeCreated.Merge(m, src)
+}
+func (m *RecipeCreated) XXX_Size() int {
+	return xxx_messageInfo_RecipeCreated.Size(m)
+}
+func (m *RecipeCreated) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecipeCreated.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecipeCreated proto.InternalMessageInfo
+
+func (m *RecipeCreated) GetMeta() *EventMeta {
+	if m != nil {
+		return m.Meta
+	}
+	return nil
+}
+
+func (m *RecipeCreated) GetRecipeId() string {
+	if m != nil {
+		return m.RecipeId
+	}
+	return ""
+}
+
+func (m *RecipeCreated) GetJobId() string {
+	if m != nil {
+		return m.JobId
+	}
+	return ""
+}
+
+type RecipeUpdated struct {
+	Meta                 *EventMeta `protobuf:"bytes,1,opt,name=meta,proto3" json:"meta,omitempty"`
+	RecipeId             string     `protobuf:"bytes,2,opt,name=recipe_id,json=recipeId,proto3" json:"recipe_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *RecipeUpdated) Reset()         { *m = RecipeUpdated{} }
+func (m *RecipeUpdated) String() string { return proto.CompactTextString(m) }
+func (*RecipeUpdated) ProtoMessage()    {}
+func (*RecipeUpdated) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f22242cb04491f9, []int{2}
+}
+
+func (m *RecipeUpdated) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecipeUpdated.Unmarshal(m, b)
+}
+func (m *RecipeUpdated) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecipeUpdated.Marshal(b, m, deterministic)
+}
+func (m *RecipeUpdated) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecipeUpdated.Merge(m, src)
+}
+func (m *RecipeUpdated) XXX_Size() int {
+	return xxx_messageInfo_RecipeUpdated.Size(m)
+}
+func (m *RecipeUpdated) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecipeUpdated.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecipeUpdated proto.InternalMessageInfo
+
+func (m *RecipeUpdated) GetMeta() *EventMeta {
+	if m != nil {
+		return m.Meta
+	}
+	return nil
+}
+
+func (m *RecipeUpdated) GetRecipeId() string {
+	if m != nil {
+		return m.RecipeId
+	}
+	return ""
+}
+
+type RecipeDeleted struct {
+	Meta                 *EventMeta `protobuf:"bytes,1,opt,name=meta,proto3" json:"meta,omitempty"`
+	RecipeId             string     `protobuf:"bytes,2,opt,name=recipe_id,json=recipeId,proto3" json:"recipe_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *RecipeDeleted) Reset()         { *m = RecipeDeleted{} }
+func (m *RecipeDeleted) String() string { return proto.CompactTextString(m) }
+func (*RecipeDeleted) ProtoMessage()    {}
+func (*RecipeDeleted) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f22242cb04491f9, []int{3}
+}
+
+func (m *RecipeDeleted) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecipeDeleted.Unmarshal(m, b)
+}
+func (m *RecipeDeleted) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecipeDeleted.Marshal(b, m, deterministic)
+}
+func (m *RecipeDeleted) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecipeDeleted.Merge(m, src)
+}
+func (m *RecipeDeleted) XXX_Size() int {
+	return xxx_messageInfo_RecipeDeleted.Size(m)
+}
+func (m *RecipeDeleted) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecipeDeleted.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecipeDeleted proto.InternalMessageInfo
+
+func (m *RecipeDeleted) GetMeta() *EventMeta {
+	if m != nil {
+		return m.Meta
+	}
+	return nil
+}
+
+func (m *RecipeDeleted) GetRecipeId() string {
+	if m != nil {
+		return m.RecipeId
+	}
+	return ""
+}
+
+type RecipeSaved struct {
+	Meta                 *EventMeta `protobuf:"bytes,1,opt,name=meta,proto3" json:"meta,omitempty"`
+	Recipe               *Recipe    `protobuf:"bytes,2,opt,name=recipe,proto3" json:"recipe,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *RecipeSaved) Reset()         { *m = RecipeSaved{} }
+func (m *RecipeSaved) String() string { return proto.CompactTextString(m) }
+func (*RecipeSaved) ProtoMessage()    {}
+func (*RecipeSaved) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f22242cb04491f9, []int{4}
+}
+
+func (m *RecipeSaved) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecipeSaved.Unmarshal(m, b)
+}
+func (m *RecipeSaved) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecipeSaved.Marshal(b, m, deterministic)
+}
+func (m *RecipeSaved) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecipeSaved.Merge(m, src)
+}
+func (m *RecipeSaved) XXX_Size() int {
+	return xxx_messageInfo_RecipeSaved.Size(m)
+}
+func (m *RecipeSaved) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecipeSaved.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecipeSaved proto.InternalMessageInfo
+
+func (m *RecipeSaved) GetMeta() *EventMeta {
+	if m != nil {
+		return m.Meta
+	}
+	return nil
+}
+
+func (m *RecipeSaved) GetRecipe() *Recipe {
+	if m != nil {
+		return m.Recipe
+	}
+	return nil
+}
+
+type Recipe struct {
+	Id                   string        `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExternalId           string        `protobuf:"bytes,2,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
+	Title                string        `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Subtitle             string        `protobuf:"bytes,4,opt,name=subtitle,proto3" json:"subtitle,omitempty"`
+	MainImage            *Image        `protobuf:"bytes,5,opt,name=main_image,json=mainImage,proto3" json:"main_image,omitempty"`
+	Likes                int32         `protobuf:"varint,6,opt,name=likes,proto3" json:"likes,omitempty"`
+	Difficulty           string        `protobuf:"bytes,7,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	Cost                 string        `protobuf:"bytes,8,opt,name=cost,proto3" json:"cost,omitempty"`
+	PrepTime             int32         `protobuf:"varint,9,opt,name=prep_time,json=prepTime,proto3" json:"prep_time,omitempty"`
+	CookTime             int32         `protobuf:"varint,10,opt,name=cook_time,json=cookTime,proto3" json:"cook_time,omitempty"`
+	Servings             int32         `protobuf:"varint,11,opt,name=servings,proto3" json:"servings,omitempty"`
+	ExtraNotes           string        `protobuf:"bytes,12,opt,name=extra_notes,json=extraNotes,proto3" json:"extra_notes,omitempty"`
+	Description          string        `protobuf:"bytes,13,opt,name=description,proto3" json:"description,omitempty"`
+	Ingredients          []*Ingredient `protobuf:"bytes,14,rep,name=ingredients,proto3" json:"ingredients,omitempty"`
+	Steps                []*Step       `protobuf:"bytes,15,rep,name=steps,proto3" json:"steps,omitempty"`
+	Tags                 string        `protobuf:"bytes,16,opt,name=tags,proto3" json:"tags,omitempty"`
+	Conclusion           string        `protobuf:"bytes,17,opt,name=conclusion,proto3" json:"conclusion,omitempty"`
+	Slug                 string        `protobuf:"bytes,18,opt,name=slug,proto3" json:"slug,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *Recipe) Reset()         { *m = Recipe{} }
+func (m *Recipe) String() string { return proto.CompactTextString(m) }
+func (*Recipe) ProtoMessage()    {}
+func (*Recipe) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f22242cb04491f9, []int{5}
+}
+
+func (m *Recipe) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Recipe.Unmarshal(m, b)
+}
+func (m *Recipe) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Recipe.Marshal(b, m, deterministic)
+}
+func (m *Recipe) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Recipe.Merge(m, src)
+}
+func (m *Recipe) XXX_Size() int {
+	return xxx_messageInfo_Recipe.Size(m)
+}
+func (m *Recipe) XXX_DiscardUnknown() {
+	xxx_messageInfo_Recipe.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Recipe proto.InternalMessageInfo
+
+func (m *Recipe) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Recipe) GetExternalId() string {
+	if m != nil {
+		return m.ExternalId
+	}
+	return ""
+}
+
+func (m *Recipe) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+func (m *Recipe) GetSubtitle() string {
+	if m != nil {
+		return m.Subtitle
+	}
+	return ""
+}
+
+func (m *Recipe) GetMainImage() *Image {
+	if m != nil {
+		return m.MainImage
+	}
+	return nil
+}
+
+func (m *Recipe) GetLikes() int32 {
+	if m != nil {
+		return m.Likes
+	}
+	return 0
+}
+
+func (m *Recipe) GetDifficulty() string {
+	if m != nil {
+		return m.Difficulty
+	}
+	return ""
+}
+
+func (m *Recipe) GetCost() string {
+	if m != nil {
+		return m.Cost
+	}
+	return ""
+}
+
+func (m *Recipe) GetPrepTime() int32 {
+	if m != nil {
+		return m.PrepTime
+	}
+	return 0
+}
+
+func (m *Recipe) GetCookTime() int32 {
+	if m != nil {
+		return m.CookTime
+	}
+	return 0
+}
+
+func (m *Recipe) GetServings() int32 {
+	if m != nil {
+		return m.Servings
+	}
+	return 0
+}
+
+func (m *Recipe) GetExtraNotes() string {
+	if m != nil {
+		return m.ExtraNotes
+	}
+	return ""
+}
+
+func (m *Recipe) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *Recipe) GetIngredients() []*Ingredient {
+	if m != nil {
+		return m.Ingredients
+	}
+	return nil
+}
+
+func (m *Recipe) GetSteps() []*Step {
+	if m != nil {
+		return m.Steps
+	}
+	return nil
+}
+
+func (m *Recipe) GetTags() string {
+	if m != nil {
+		return m.Tags
+	}
+	return ""
+}
+
+func (m *Recipe) GetConclusion() string {
+	if m != nil {
+		return m.Conclusion
+	}
+	return ""
+}
+
+func (m *Recipe) GetSlug() string {
+	if m != nil {
+		return m.Slug
+	}
+	return ""
+}
+
+type Ingredient struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Quantity             string   `protobuf:"bytes,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	UnitOfMeasure        string   `protobuf:"bytes,3,opt,name=unit_of_measure,json=unitOfMeasure,proto3" json:"unit_of_measure,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Ingredient) Reset()         { *m = Ingredient{} }
+func (m *Ingredient) String() string { return proto.CompactTextString(m) }
+func (*Ingredient) ProtoMessage()    {}
+func (*Ingredient) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f22242cb04491f9, []int{6}
+}
+
+func (m *Ingredient) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Ingredient.Unmarshal(m, b)
+}
+func (m *Ingredient) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Ingredient.Marshal(b, m, deterministic)
+}
+func (m *Ingredient) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Ingredient.Merge(m, src)
+}
+func (m *Ingredient) XXX_Size() int {
+	return xxx_messageInfo_Ingredient.Size(m)
+}
+func (m *Ingredient) XXX_DiscardUnknown() {
+	xxx_messageInfo_Ingredient.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Ingredient proto.InternalMessageInfo
+
+func (m *Ingredient) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Ingredient) GetQuantity() string {
+	if m != nil {
+		return m.Quantity
+	}
+	return ""
+}
+
+func (m *Ingredient) GetUnitOfMeasure() string {
+	if m != nil {
+		return m.UnitOfMeasure
+	}
+	return ""
+}
+
+type Step struct {
+	Heading              string   `protobuf:"bytes,1,opt,name=heading,proto3" json:"heading,omitempty"`
+	Body                 string   `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
+	Image                *Image   `protobuf:"bytes,3,opt,name=image,proto3" json:"image,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Step) Reset()         { *m = Step{} }
+func (m *Step) String() string { return proto.CompactTextString(m) }
+func (*Step) ProtoMessage()    {}
+func (*Step) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f22242cb04491f9, []int{7}
+}
+
+func (m *Step) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Step.Unmarshal(m, b)
+}
+func (m *Step) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Step.Marshal(b, m, deterministic)
+}
+func (m *Step) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Step.Merge(m, src)
+}
+func (m *Step) XXX_Size() int {
+	return xxx_messageInfo_Step.Size(m)
+}
+func (m *Step) XXX_DiscardUnknown() {
+	xxx_messageInfo_Step.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Step proto.InternalMessageInfo
+
+func (m *Step) GetHeading() string {
+	if m != nil {
+		return m.Heading
+	}
+	return ""
+}
+
+func (m *Step) GetBody() string {
+	if m != nil {
+		return m.Body
+	}
+	return ""
+}
+
+func (m *Step) GetImage() *Image {
+	if m != nil {
+		return m.Image
+	}
+	return nil
+}
+
+type Image struct {
+	Url                  string   `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Image) Reset()         { *m = Image{} }
+func (m *Image) String() string { return proto.CompactTextString(m) }
+func (*Image) ProtoMessage()    {}
+func (*Image) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f22242cb04491f9, []int{8}
+}
+
+func (m *Image) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Image.Unmarshal(m, b)
+}
+func (m *Image) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Image.Marshal(b, m, deterministic)
+}
+func (m *Image) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Image.Merge(m, src)
+}
+func (m *Image) XXX_Size() int {
+	return xxx_messageInfo_Image.Size(m)
+}
+func (m *Image) XXX_DiscardUnknown() {
+	xxx_messageInfo_Image.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Image proto.InternalMessageInfo
+
+func (m *Image) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*EventMeta)(nil), "EventMeta")
+	proto.RegisterType((*RecipeCreated)(nil), "RecipeCreated")
+	proto.RegisterType((*RecipeUpdated)(nil), "RecipeUpdated")
+	proto.RegisterType((*RecipeDeleted)(nil), "RecipeDeleted")
+	proto.RegisterType((*RecipeSaved)(nil), "RecipeSaved")
+	proto.RegisterType((*Recipe)(nil), "Recipe")
+	proto.RegisterType((*Ingredient)(nil), "Ingredient")
+	proto.RegisterType((*Step)(nil), "Step")
+	proto.RegisterType((*Image)(nil), "Image")
+}
+
+func init() {
+	proto.RegisterFile("events.proto", fileDescriptor_8f22242cb04491f9)
+}
+
+var fileDescriptor_8f22242cb04491f9 = []byte{
+	// 612 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xcb, 0x6e, 0xdb, 0x3a,
+	0x10, 0x85, 0x63, 0xcb, 0x8f, 0x51, 0x5e, 0x97, 0xb8, 0xf7, 0x82, 0x4d, 0x8a, 0xc4, 0x10, 0xd0,
+	0xc2, 0x9b, 0x2a, 0x80, 0xbb, 0xe9, 0xba, 0x8f, 0x85, 0x81, 0x26, 0x05, 0x94, 0x76, 0xd3, 0x8d,
+	0x41, 0x4b, 0x63, 0x95, 0x89, 0x24, 0xaa, 0x24, 0x65, 0x34, 0x5f, 0x50, 0xf4, 0xaf, 0x8b, 0x21,
+	0x25, 0x39, 0xdd, 0x15, 0xc8, 0x4a, 0x73, 0xce, 0x99, 0x17, 0x87, 0x43, 0xc1, 0x21, 0xee, 0xb0,
+	0xb2, 0x26, 0xae, 0xb5, 0xb2, 0xea, 0xec, 0x32, 0x57, 0x2a, 0x2f, 0xf0, 0xca, 0xa1, 0x4d, 0xb3,
+	0xbd, 0xb2, 0xb2, 0x44, 0x63, 0x45, 0x59, 0x7b, 0x87, 0xe8, 0xe7, 0x00, 0x66, 0x1f, 0x28, 0xe2,
+	0x1a, 0xad, 0x60, 0x1c, 0x26, 0x3b, 0xd4, 0x46, 0xaa, 0x8a, 0x0f, 0xe6, 0x83, 0x45, 0x90, 0x74,
+	0x90, 0x1d, 0xc3, 0x81, 0xcc, 0xf8, 0xc1, 0x7c, 0xb0, 0x98, 0x25, 0x07, 0x32, 0x63, 0x6f, 0x60,
+	0xd6, 0xa7, 0xe2, 0xc3, 0xf9, 0x60, 0x11, 0x2e, 0xcf, 0x62, 0x5f, 0x2c, 0xee, 0x8a, 0xc5, 0x9f,
+	0x3b, 0x8f, 0x64, 0xef, 0xcc, 0xfe, 0x87, 0xb1, 0x51, 0x8d, 0x4e, 0x91, 0x8f, 0x5c, 0xb6, 0x16,
+	0x45, 0x29, 0x1c, 0x25, 0x98, 0xca, 0x1a, 0xdf, 0x69, 0x14, 0x16, 0x33, 0x76, 0x01, 0xa3, 0x12,
+	0xad, 0x70, 0x9d, 0x84, 0x4b, 0x88, 0xfb, 0x36, 0x13, 0xc7, 0xb3, 0x73, 0x98, 0x69, 0x17, 0xb0,
+	0xee, 0x3b, 0x9b, 0x7a, 0x62, 0x95, 0xb1, 0xff, 0x60, 0x7c, 0xa7, 0x36, 0xa4, 0x0c, 0x9d, 0x12,
+	0xdc, 0xa9, 0xcd, 0x2a, 0x8b, 0x3e, 0x76, 0x45, 0xbe, 0xd4, 0xd9, 0x93, 0x8b, 0xec, 0xb3, 0xbd,
+	0xc7, 0x02, 0x9f, 0x9c, 0xed, 0x06, 0x42, 0x9f, 0xed, 0x56, 0xec, 0xfe, 0x22, 0xd7, 0x25, 0x8c,
+	0x7d, 0xa8, 0x4b, 0x14, 0x2e, 0x27, 0xb1, 0x8f, 0x4e, 0x5a, 0x3a, 0xfa, 0x35, 0x82, 0xb1, 0xa7,
+	0xda, 0xdb, 0x1b, 0xf4, 0xb7, 0x77, 0x09, 0x21, 0xfe, 0xb0, 0xa8, 0x2b, 0x51, 0xec, 0x3b, 0x81,
+	0x8e, 0x5a, 0x65, 0xec, 0x5f, 0x08, 0xac, 0xb4, 0x05, 0x76, 0xd3, 0x73, 0x80, 0x9d, 0xc1, 0xd4,
+	0x34, 0x1b, 0x2f, 0xf8, 0xcb, 0xeb, 0x31, 0x7b, 0x01, 0x50, 0x0a, 0x59, 0xad, 0x65, 0x29, 0x72,
+	0xe4, 0x81, 0x6b, 0x69, 0x1c, 0xaf, 0x08, 0x25, 0x33, 0x52, 0x9c, 0x49, 0x89, 0x0b, 0x79, 0x8f,
+	0x86, 0x8f, 0xdd, 0x7e, 0x79, 0xc0, 0x2e, 0x00, 0x32, 0xb9, 0xdd, 0xca, 0xb4, 0x29, 0xec, 0x03,
+	0x9f, 0xf8, 0x76, 0xf6, 0x0c, 0x63, 0x30, 0x4a, 0x95, 0xb1, 0x7c, 0xea, 0x14, 0x67, 0xd3, 0x2c,
+	0x6b, 0x8d, 0xf5, 0x9a, 0x36, 0x8b, 0xcf, 0x5c, 0xb6, 0x29, 0x11, 0xb4, 0x74, 0x24, 0xa6, 0x4a,
+	0xdd, 0x7b, 0x11, 0xbc, 0x48, 0x84, 0x13, 0xe9, 0x18, 0xa8, 0x77, 0xb2, 0xca, 0x0d, 0x0f, 0xbd,
+	0xd6, 0xe1, 0x76, 0x32, 0x5a, 0xac, 0x2b, 0x65, 0xd1, 0xf0, 0xc3, 0x7e, 0x32, 0x5a, 0xdc, 0x10,
+	0xc3, 0xe6, 0x10, 0x66, 0x68, 0x52, 0x2d, 0x6b, 0x4b, 0xcf, 0xe4, 0xc8, 0x39, 0x3c, 0xa6, 0xd8,
+	0x2b, 0x08, 0x65, 0x95, 0x6b, 0xcc, 0x24, 0x3d, 0x44, 0x7e, 0x3c, 0x1f, 0x2e, 0xc2, 0x65, 0x18,
+	0xaf, 0x7a, 0x2e, 0x79, 0xac, 0xb3, 0x73, 0x08, 0x8c, 0xc5, 0xda, 0xf0, 0x13, 0xe7, 0x18, 0xc4,
+	0xb7, 0x16, 0xeb, 0xc4, 0x73, 0x74, 0x70, 0x2b, 0x72, 0xc3, 0x4f, 0xfd, 0xc1, 0xc9, 0xa6, 0x61,
+	0xa5, 0xaa, 0x4a, 0x8b, 0xc6, 0xbd, 0xd3, 0x7f, 0x7c, 0x87, 0x7b, 0x86, 0x62, 0x4c, 0xd1, 0xe4,
+	0x9c, 0xf9, 0x18, 0xb2, 0xa3, 0x0c, 0x60, 0x5f, 0x9f, 0x3c, 0x2a, 0x51, 0x62, 0xbb, 0x10, 0xce,
+	0xa6, 0xa1, 0x7c, 0x6f, 0x44, 0x65, 0xa5, 0x7d, 0xe8, 0x36, 0xb3, 0xc3, 0xec, 0x25, 0x9c, 0x34,
+	0x95, 0xb4, 0x6b, 0xb5, 0x5d, 0x97, 0x28, 0x4c, 0xa3, 0xbb, 0xbd, 0x38, 0x22, 0xfa, 0xd3, 0xf6,
+	0xda, 0x93, 0x51, 0x02, 0x23, 0x6a, 0x9e, 0x7e, 0x23, 0xdf, 0x50, 0x64, 0xb2, 0xca, 0xdb, 0x12,
+	0x1d, 0xa4, 0xca, 0x1b, 0x95, 0x75, 0x15, 0x9c, 0xcd, 0x9e, 0x43, 0xe0, 0x97, 0x66, 0xf8, 0xc7,
+	0xd2, 0x78, 0x32, 0x7a, 0x06, 0x81, 0xc3, 0xec, 0x14, 0x86, 0x8d, 0x2e, 0xda, 0x84, 0x64, 0xbe,
+	0x9d, 0x7c, 0x0d, 0xfc, 0xaf, 0x66, 0xec, 0x3e, 0xaf, 0x7f, 0x0f, 0x00, 0x7f, 0x98, 0xd0, 0x03,
+	0xfc, 0x04, 0x00, 0x00,
+}
@@ -94,9 +94,218 @@ func (m *AllRecipeTagsResponse) GetTags() []string {
 	return nil
 }
 
+type StreamsInfoRequest struct {
+	Streams              []string `protobuf:"bytes,1,rep,name=streams,proto3" json:"streams,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StreamsInfoRequest) Reset()         { *m = StreamsInfoRequest{} }
+func (m *StreamsInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*StreamsInfoRequest) ProtoMessage()    {}
+func (*StreamsInfoRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_02dfec63316bfb34, []int{2}
+}
+
+func (m *StreamsInfoRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StreamsInfoRequest.Unmarshal(m, b)
+}
+func (m *StreamsInfoRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StreamsInfoRequest.Marshal(b, m, deterministic)
+}
+func (m *StreamsInfoRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamsInfoRequest.Merge(m, src)
+}
+func (m *StreamsInfoRequest) XXX_Size() int {
+	return xxx_messageInfo_StreamsInfoRequest.Size(m)
+}
+func (m *StreamsInfoRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamsInfoRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamsInfoRequest proto.InternalMessageInfo
+
+func (m *StreamsInfoRequest) GetStreams() []string {
+	if m != nil {
+		return m.Streams
+	}
+	return nil
+}
+
+type StreamsInfoResponse struct {
+	Streams              []*StreamInfo `protobuf:"bytes,1,rep,name=streams,proto3" json:"streams,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *StreamsInfoResponse) Reset()         { *m = StreamsInfoResponse{} }
+func (m *StreamsInfoResponse) String() string { return proto.CompactTextString(m) }
+func (*StreamsInfoResponse) ProtoMessage()    {}
+func (*StreamsInfoResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_02dfec63316bfb34, []int{3}
+}
+
+func (m *StreamsInfoResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StreamsInfoResponse.Unmarshal(m, b)
+}
+func (m *StreamsInfoResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StreamsInfoResponse.Marshal(b, m, deterministic)
+}
+func (m *StreamsInfoResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamsInfoResponse.Merge(m, src)
+}
+func (m *StreamsInfoResponse) XXX_Size() int {
+	return xxx_messageInfo_StreamsInfoResponse.Size(m)
+}
+func (m *StreamsInfoResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamsInfoResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamsInfoResponse proto.InternalMessageInfo
+
+func (m *StreamsInfoResponse) GetStreams() []*StreamInfo {
+	if m != nil {
+		return m.Streams
+	}
+	return nil
+}
+
+type StreamInfo struct {
+	Stream               string       `protobuf:"bytes,1,opt,name=stream,proto3" json:"stream,omitempty"`
+	Length               int64        `protobuf:"varint,2,opt,name=length,proto3" json:"length,omitempty"`
+	Groups               []*GroupInfo `protobuf:"bytes,3,rep,name=groups,proto3" json:"groups,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *StreamInfo) Reset()         { *m = StreamInfo{} }
+func (m *StreamInfo) String() string { return proto.CompactTextString(m) }
+func (*StreamInfo) ProtoMessage()    {}
+func (*StreamInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_02dfec63316bfb34, []int{4}
+}
+
+func (m *StreamInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StreamInfo.Unmarshal(m, b)
+}
+func (m *StreamInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StreamInfo.Marshal(b, m, deterministic)
+}
+func (m *StreamInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamInfo.Merge(m, src)
+}
+func (m *StreamInfo) XXX_Size() int {
+	return xxx_messageInfo_StreamInfo.Size(m)
+}
+func (m *StreamInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamInfo proto.InternalMessageInfo
+
+func (m *StreamInfo) GetStream() string {
+	if m != nil {
+		return m.Stream
+	}
+	return ""
+}
+
+func (m *StreamInfo) GetLength() int64 {
+	if m != nil {
+		return m.Length
+	}
+	return 0
+}
+
+func (m *StreamInfo) GetGroups() []*GroupInfo {
+	if m != nil {
+		return m.Groups
+	}
+	return nil
+}
+
+type GroupInfo struct {
+	Name                 string           `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	LastDeliveredId      string           `protobuf:"bytes,2,opt,name=last_delivered_id,json=lastDeliveredId,proto3" json:"last_delivered_id,omitempty"`
+	Pending              int64            `protobuf:"varint,3,opt,name=pending,proto3" json:"pending,omitempty"`
+	OldestPendingIdleMs  int64            `protobuf:"varint,4,opt,name=oldest_pending_idle_ms,json=oldestPendingIdleMs,proto3" json:"oldest_pending_idle_ms,omitempty"`
+	Consumers            map[string]int64 `protobuf:"bytes,5,rep,name=consumers,proto3" json:"consumers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *GroupInfo) Reset()         { *m = GroupInfo{} }
+func (m *GroupInfo) String() string { return proto.CompactTextString(m) }
+func (*GroupInfo) ProtoMessage()    {}
+func (*GroupInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_02dfec63316bfb34, []int{5}
+}
+
+func (m *GroupInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GroupInfo.Unmarshal(m, b)
+}
+func (m *GroupInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GroupInfo.Marshal(b, m, deterministic)
+}
+func (m *GroupInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GroupInfo.Merge(m, src)
+}
+func (m *GroupInfo) XXX_Size() int {
+	return xxx_messageInfo_GroupInfo.Size(m)
+}
+func (m *GroupInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_GroupInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GroupInfo proto.InternalMessageInfo
+
+func (m *GroupInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *GroupInfo) GetLastDeliveredId() string {
+	if m != nil {
+		return m.LastDeliveredId
+	}
+	return ""
+}
+
+func (m *GroupInfo) GetPending() int64 {
+	if m != nil {
+		return m.Pending
+	}
+	return 0
+}
+
+func (m *GroupInfo) GetOldestPendingIdleMs() int64 {
+	if m != nil {
+		return m.OldestPendingIdleMs
+	}
+	return 0
+}
+
+func (m *GroupInfo) GetConsumers() map[string]int64 {
+	if m != nil {
+		return m.Consumers
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*AllRecipeTagsRequest)(nil), "AllRecipeTagsRequest")
 	proto.RegisterType((*AllRecipeTagsResponse)(nil), "AllRecipeTagsResponse")
+	proto.RegisterType((*StreamsInfoRequest)(nil), "StreamsInfoRequest")
+	proto.RegisterType((*StreamsInfoResponse)(nil), "StreamsInfoResponse")
+	proto.RegisterType((*StreamInfo)(nil), "StreamInfo")
+	proto.RegisterType((*GroupInfo)(nil), "GroupInfo")
+	proto.RegisterMapType((map[string]int64)(nil), "GroupInfo.ConsumersEntry")
 }
 
 func init() {
@@ -104,15 +313,32 @@ func init() {
 }
 
 var fileDescriptor_02dfec63316bfb34 = []byte{
-	// 128 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x49, 0xcb, 0xcc, 0x4b,
-	0x49, 0x2d, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x57, 0x12, 0xe3, 0x12, 0x71, 0xcc, 0xc9, 0x09,
-	0x4a, 0x4d, 0xce, 0x2c, 0x48, 0x0d, 0x49, 0x4c, 0x2f, 0x0e, 0x4a, 0x2d, 0x2c, 0x4d, 0x2d, 0x2e,
-	0x51, 0xd2, 0xe6, 0x12, 0x45, 0x13, 0x2f, 0x2e, 0xc8, 0xcf, 0x2b, 0x4e, 0x15, 0x12, 0xe2, 0x62,
-	0x29, 0x49, 0x4c, 0x2f, 0x96, 0x60, 0x54, 0x60, 0xd6, 0xe0, 0x0c, 0x02, 0xb3, 0x8d, 0xbc, 0xb8,
-	0xd8, 0xdc, 0xc0, 0x86, 0x0a, 0x39, 0x70, 0xf1, 0xa2, 0x68, 0x13, 0x12, 0xd5, 0xc3, 0x66, 0xbc,
-	0x94, 0x98, 0x1e, 0x56, 0xd3, 0x95, 0x18, 0x9c, 0xd8, 0xa3, 0x58, 0xc1, 0x2e, 0x4b, 0x62, 0x03,
-	0x53, 0xc6, 0x80, 0x00, 0x00, 0x00, 0xff, 0xff, 0x9d, 0x11, 0xad, 0x0f, 0xb0, 0x00, 0x00, 0x00,
+	// 394 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x52, 0x4b, 0x8f, 0xd3, 0x30,
+	0x10, 0xde, 0x34, 0xdb, 0xac, 0x32, 0xe5, 0xe9, 0x76, 0x23, 0xd3, 0x53, 0x65, 0x09, 0xa9, 0x02,
+	0xc9, 0x87, 0xdd, 0x03, 0xa8, 0xea, 0x81, 0x37, 0xea, 0x01, 0x09, 0x05, 0x4e, 0x5c, 0x42, 0xa8,
+	0xa7, 0x21, 0xc2, 0x71, 0x42, 0xec, 0x54, 0xea, 0x1f, 0xe0, 0xce, 0x3f, 0x46, 0x71, 0x9c, 0x96,
+	0x40, 0x4f, 0x99, 0xef, 0xe1, 0x99, 0xcc, 0xe8, 0x83, 0x3b, 0xbb, 0x5c, 0x09, 0xac, 0x79, 0x55,
+	0x97, 0xa6, 0x64, 0x11, 0xcc, 0x5e, 0x4a, 0x19, 0xe3, 0x36, 0xaf, 0xf0, 0x73, 0x9a, 0xe9, 0x18,
+	0x7f, 0x36, 0xa8, 0x0d, 0x7b, 0x0a, 0xd7, 0xff, 0xf0, 0xba, 0x2a, 0x95, 0x46, 0x42, 0xe0, 0xd2,
+	0xa4, 0x99, 0xa6, 0xde, 0xc2, 0x5f, 0x86, 0xb1, 0xad, 0x19, 0x07, 0xf2, 0xc9, 0xd4, 0x98, 0x16,
+	0x7a, 0xa3, 0x76, 0xa5, 0x6b, 0x41, 0x28, 0x5c, 0xe9, 0x8e, 0x75, 0xe6, 0x1e, 0xb2, 0x35, 0x4c,
+	0x07, 0x7e, 0xd7, 0xfa, 0xf1, 0xf0, 0xc1, 0xe4, 0x66, 0xc2, 0x3b, 0x9b, 0x75, 0x1d, 0x5f, 0x7f,
+	0x05, 0x38, 0xd1, 0x24, 0x82, 0xa0, 0x13, 0xa8, 0xb7, 0xf0, 0x96, 0x61, 0xec, 0x50, 0xcb, 0x4b,
+	0x54, 0x99, 0xf9, 0x4e, 0x47, 0x0b, 0x6f, 0xe9, 0xc7, 0x0e, 0x11, 0x06, 0x41, 0x56, 0x97, 0x4d,
+	0xa5, 0xa9, 0x6f, 0x67, 0x00, 0x7f, 0xdf, 0x42, 0x3b, 0xc2, 0x29, 0xec, 0xf7, 0x08, 0xc2, 0x23,
+	0xdb, 0x6e, 0xac, 0xd2, 0x02, 0x5d, 0x7f, 0x5b, 0x93, 0x27, 0xf0, 0x50, 0xa6, 0xda, 0x24, 0x02,
+	0x65, 0xbe, 0xc7, 0x1a, 0x45, 0x92, 0x0b, 0x3b, 0x28, 0x8c, 0xef, 0xb7, 0xc2, 0x9b, 0x9e, 0xdf,
+	0x88, 0xf6, 0x0e, 0x15, 0x2a, 0x91, 0xab, 0x8c, 0xfa, 0xf6, 0x57, 0x7a, 0x48, 0x6e, 0x21, 0x2a,
+	0xa5, 0x40, 0x6d, 0x12, 0xc7, 0x24, 0xb9, 0x90, 0x98, 0x14, 0x9a, 0x5e, 0x5a, 0xe3, 0xb4, 0x53,
+	0x3f, 0x76, 0xe2, 0x46, 0x48, 0xfc, 0xa0, 0xc9, 0x33, 0x08, 0xb7, 0xa5, 0xd2, 0x4d, 0x81, 0xb5,
+	0xa6, 0x63, 0xbb, 0xc3, 0xa3, 0xd3, 0x0e, 0xfc, 0x75, 0xaf, 0xbd, 0x55, 0xa6, 0x3e, 0xc4, 0x27,
+	0xef, 0x7c, 0x0d, 0xf7, 0x86, 0x22, 0x79, 0x00, 0xfe, 0x0f, 0x3c, 0xb8, 0xc5, 0xda, 0x92, 0xcc,
+	0x60, 0xbc, 0x4f, 0x65, 0x83, 0xee, 0x68, 0x1d, 0x58, 0x8d, 0x9e, 0x7b, 0x37, 0xbf, 0x3c, 0x08,
+	0xde, 0xd9, 0xe4, 0x90, 0x17, 0x70, 0x77, 0x90, 0x0d, 0x72, 0xcd, 0xcf, 0x65, 0x68, 0x1e, 0xf1,
+	0xb3, 0x11, 0x62, 0x17, 0x64, 0x05, 0x93, 0xbf, 0x02, 0x40, 0xa6, 0xfc, 0xff, 0xf8, 0xcc, 0x67,
+	0xfc, 0x4c, 0x46, 0xd8, 0xc5, 0xab, 0xab, 0x2f, 0x63, 0x1b, 0xdd, 0x6f, 0x81, 0xfd, 0xdc, 0xfe,
+	0x19, 0x00, 0x6b, 0x80, 0xd2, 0x88, 0xd1, 0x02, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -128,6 +354,7 @@ const _ = grpc.SupportPackageIsVersion6
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type FinderClient interface {
 	AllRecipeTags(ctx context.Context, in *AllRecipeTagsRequest, opts ...grpc.CallOption) (*AllRecipeTagsResponse, error)
+	StreamsInfo(ctx context.Context, in *StreamsInfoRequest, opts ...grpc.CallOption) (*StreamsInfoResponse, error)
 }
 
 type finderClient struct {
@@ -147,9 +374,19 @@ func (c *finderClient) AllRecipeTags(ctx context.Context, in *AllRecipeTagsReque
 	return out, nil
 }
 
+func (c *finderClient) StreamsInfo(ctx context.Context, in *StreamsInfoRequest, opts ...grpc.CallOption) (*StreamsInfoResponse, error) {
+	out := new(StreamsInfoResponse)
+	err := c.cc.Invoke(ctx, "/Finder/StreamsInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FinderServer is the server API for Finder service.
 type FinderServer interface {
 	AllRecipeTags(context.Context, *AllRecipeTagsRequest) (*AllRecipeTagsResponse, error)
+	StreamsInfo(context.Context, *StreamsInfoRequest) (*StreamsInfoResponse, error)
 }
 
 // UnimplementedFinderServer can be embedded to have forward compatible implementations.
@@ -159,6 +396,9 @@ type UnimplementedFinderServer struct {
 func (*UnimplementedFinderServer) AllRecipeTags(ctx context.Context, req *AllRecipeTagsRequest) (*AllRecipeTagsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AllRecipeTags not implemented")
 }
+func (*UnimplementedFinderServer) StreamsInfo(ctx context.Context, req *StreamsInfoRequest) (*StreamsInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StreamsInfo not implemented")
+}
 
 func RegisterFinderServer(s *grpc.Server, srv FinderServer) {
 	s.RegisterService(&_Finder_serviceDesc, srv)
@@ -182,6 +422,24 @@ func _Finder_AllRecipeTags_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Finder_StreamsInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StreamsInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FinderServer).StreamsInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Finder/StreamsInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FinderServer).StreamsInfo(ctx, req.(*StreamsInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Finder_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "Finder",
 	HandlerType: (*FinderServer)(nil),
@@ -190,6 +448,10 @@ var _Finder_serviceDesc = grpc.ServiceDesc{
 			MethodName: "AllRecipeTags",
 			Handler:    _Finder_AllRecipeTags_Handler,
 		},
+		{
+			MethodName: "StreamsInfo",
+			Handler:    _Finder_StreamsInfo_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "finder.proto",
@@ -0,0 +1,9 @@
+local last = redis.call("get", KEYS[2])
+if last and tonumber(ARGV[1]) < tonumber(last) then
+	return 0
+end
+if not redis.call("set", KEYS[1], ARGV[1], "nx", "px", ARGV[2]) then
+	return 0
+end
+redis.call("set", KEYS[2], ARGV[1], "px", ARGV[2])
+return 1
@@ -0,0 +1,2 @@
+redis.call("set", KEYS[2], ARGV[2], "px", ARGV[3])
+return redis.call("xack", KEYS[1], ARGV[1], ARGV[2])
@@ -0,0 +1,12 @@
+if not redis.call("set", KEYS[1], ARGV[1], "nx", "px", ARGV[3]) then
+	return 0
+end
+-- the last job lost the lock while running, it stalled
+if KEYS[4] ~= KEYS[2] and redis.call("hget", KEYS[4], "state") == "running" then
+	redis.call("hset", KEYS[4], "state", "failed", "finishedAt", ARGV[2], "error", "stalled")
+	redis.call("pexpire", KEYS[4], ARGV[4])
+	redis.call("pexpire", KEYS[5], ARGV[4])
+end
+redis.call("hset", KEYS[2], "id", ARGV[1], "state", "running", "createdAt", ARGV[2])
+redis.call("set", KEYS[3], ARGV[1])
+return 1
@@ -0,0 +1,5 @@
+if redis.call("xack", KEYS[1], ARGV[1], ARGV[2]) == 1 then
+	redis.call("hdel", KEYS[3], ARGV[2])
+	return redis.call("xadd", KEYS[2], "*", "message", ARGV[3], "stream", KEYS[1], "group", ARGV[1], "id", ARGV[2], "reason", ARGV[4], "attempts", ARGV[5])
+end
+return false
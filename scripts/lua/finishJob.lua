@@ -0,0 +1,11 @@
+local job = redis.call("hmget", KEYS[1], "id", "state")
+if job[2] ~= "running" then
+	return 0
+end
+redis.call("hset", KEYS[1], "state", ARGV[1], "finishedAt", ARGV[2], "error", ARGV[4])
+if redis.call("get", KEYS[3]) == job[1] then
+	redis.call("del", KEYS[3])
+end
+redis.call("pexpire", KEYS[1], ARGV[3])
+redis.call("pexpire", KEYS[2], ARGV[3])
+return 1
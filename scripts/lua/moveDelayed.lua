@@ -0,0 +1,7 @@
+local msgs = redis.call("zrangebyscore", KEYS[1], "-inf", ARGV[1], "limit", 0, ARGV[2])
+for _, m in ipairs(msgs) do
+	local i = string.find(m, ":", 1, true)
+	redis.call("xadd", KEYS[2], "*", "message", string.sub(m, i + 1))
+	redis.call("zrem", KEYS[1], m)
+end
+return #msgs
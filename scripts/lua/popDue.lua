@@ -0,0 +1,5 @@
+local ids = redis.call("zrangebyscore", KEYS[1], "-inf", ARGV[1], "limit", 0, ARGV[2])
+if #ids > 0 then
+	redis.call("zrem", KEYS[1], unpack(ids))
+end
+return ids
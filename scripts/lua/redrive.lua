@@ -0,0 +1,13 @@
+local entries = redis.call("xrange", KEYS[1], ARGV[1], ARGV[1])
+if #entries == 0 then
+	return false
+end
+local fields = entries[1][2]
+for i = 1, #fields, 2 do
+	if fields[i] == "message" then
+		local id = redis.call("xadd", KEYS[2], "*", "message", fields[i + 1])
+		redis.call("xdel", KEYS[1], ARGV[1])
+		return id
+	end
+end
+return false
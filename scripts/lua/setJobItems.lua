@@ -0,0 +1,23 @@
+local n = 0
+for i = 5, #ARGV do
+	if redis.call("hget", KEYS[2], ARGV[i]) == "queued" then
+		redis.call("hset", KEYS[2], ARGV[i], ARGV[1])
+		redis.call("hincrby", KEYS[1], "queued", -1)
+		redis.call("hincrby", KEYS[1], ARGV[1], 1)
+		n = n + 1
+	end
+end
+local job = redis.call("hmget", KEYS[1], "id", "state", "loaded", "queued")
+local locked = redis.call("get", KEYS[3]) == job[1]
+-- done once all the recipes sent over the stream are handled
+if job[2] == "running" and job[3] == "1" and tonumber(job[4] or "0") <= 0 then
+	redis.call("hset", KEYS[1], "state", "done", "finishedAt", ARGV[2])
+	if locked then
+		redis.call("del", KEYS[3])
+	end
+	redis.call("pexpire", KEYS[1], ARGV[3])
+	redis.call("pexpire", KEYS[2], ARGV[3])
+elseif locked then
+	redis.call("pexpire", KEYS[3], ARGV[4])
+end
+return n
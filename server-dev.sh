@@ -25,7 +25,7 @@ PATH="$GOPATH/bin:$PATH"
 # The Go version used for release builds must match this version.
 GOVERSION=$2
 if [ -z "$GOVERSION" ]; then
-	GOVERSION="1.14.4"
+	GOVERSION="1.20.12"
 fi
 
 # Turn off go modules by default. Only enable go modules when needed.
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gospiga/pkg/httperr"
+)
+
+// GetJob returns the progress of the job.
+func (s *GospigaService) GetJob(c *gin.Context) {
+	job, err := s.app.GetJob(c.Copy().Request.Context(), c.Param("id"))
+	if err != nil {
+		httperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+// CancelJob stops the job if running.
+func (s *GospigaService) CancelJob(c *gin.Context) {
+	job, err := s.app.CancelJob(c.Copy().Request.Context(), c.Param("id"))
+	if err != nil {
+		httperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
@@ -2,7 +2,9 @@ package api
 
 import (
 	"context"
+	"time"
 
+	"gospiga/pkg/streamer"
 	"gospiga/pkg/types"
 )
 
@@ -11,6 +13,28 @@ type App interface {
 	NewRecipe(context.Context, string) error
 	UpdatedRecipe(context.Context, string) error
 	DeletedRecipe(context.Context, string) error
+	PublishedRecipe(context.Context, string) error
 	AllTagsImages(context.Context) ([]*types.Tag, error)
-	LoadRecipes(ctx context.Context) error
+	GetRecipe(ctx context.Context, recipeID string) (*types.Recipe, error)
+	GetRecipeBySlug(ctx context.Context, slug string) (*types.Recipe, error)
+	GetRecipes(ctx context.Context, recipeIDs []string) ([]*types.Recipe, []string, error)
+	LoadRecipes(ctx context.Context) (*types.Job, error)
+	GetJob(ctx context.Context, jobID string) (*types.Job, error)
+	CancelJob(ctx context.Context, jobID string) (*types.Job, error)
+	Reconcile(ctx context.Context, dryRun bool) (*types.Reconciliation, error)
+	DeadLetters(ctx context.Context, stream string, count int64) ([]*streamer.DeadLetter, error)
+	DeadLetter(ctx context.Context, stream, id string) (*streamer.DeadLetter, error)
+	Redrive(ctx context.Context, stream, id string) (string, error)
+	ConsumerStats(ctx context.Context) streamer.Stats
+	StreamsInfo(ctx context.Context, streams []string) ([]*streamer.StreamInfo, error)
+	SetGroupID(ctx context.Context, stream, group, id string) error
+}
+
+// ReplayGuard records the webhook events received.
+type ReplayGuard interface {
+	// Accept records the event of the entity, reporting false if it's a
+	// replay.
+	Accept(ctx context.Context, eventID, entityID string, ts time.Time) (bool, error)
+	// Forget the event, so that it's accepted if received again.
+	Forget(ctx context.Context, eventID string) error
 }
@@ -1,75 +1,129 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
-)
 
-// NewRecipeRequest.
-type NewRecipeRequest struct {
-	Message  string `json:"message"`
-	EntityID string `json:"entity_id"`
-}
+	errs "gospiga/pkg/errors"
+	"gospiga/pkg/httperr"
+)
 
-// NewRecipe listens for new recipe IDs.
-func (s *GospigaService) NewRecipe(c *gin.Context) {
-	var req NewRecipeRequest
-	err := c.BindJSON(&req)
+// GetRecipe returns the recipe with the given external ID.
+func (s *GospigaService) GetRecipe(c *gin.Context) {
+	r, err := s.app.GetRecipe(c.Copy().Request.Context(), c.Param("xid"))
 	if err != nil {
-		c.Error(err)
+		httperr.Abort(c, err)
+		return
 	}
+	cacheJSON(c, gin.H{"recipe": r})
+}
 
-	err = s.app.NewRecipe(c.Copy().Request.Context(), req.EntityID)
+// GetRecipeBySlug returns the recipe with the given slug, served under
+// /recipes/by-slug/:slug.
+func (s *GospigaService) GetRecipeBySlug(c *gin.Context) {
+	if c.Param("xid") != "by-slug" {
+		httperr.Abort(c, errs.NotFound(fmt.Errorf("path %q not found", c.Request.URL.Path)))
+		return
+	}
+	r, err := s.app.GetRecipeBySlug(c.Copy().Request.Context(), c.Param("slug"))
 	if err != nil {
-		c.Error(err)
+		httperr.Abort(c, err)
+		return
 	}
+	cacheJSON(c, gin.H{"recipe": r})
 }
 
-// UpdatedRecipeRequest.
-type UpdatedRecipeRequest struct {
-	Message  string `json:"message"`
-	EntityID string `json:"entity_id"`
+// RecipesRequest lists the external IDs of the recipes to get.
+type RecipesRequest struct {
+	IDs []string `json:"ids"`
 }
 
-// UpdatedRecipe listens for IDs of recipes that have been updated.
-func (s *GospigaService) UpdatedRecipe(c *gin.Context) {
-	var req UpdatedRecipeRequest
-	err := c.BindJSON(&req)
+// GetRecipes returns the recipes with the given external IDs, in the same
+// order, along with the IDs not found.
+func (s *GospigaService) GetRecipes(c *gin.Context) {
+	var req RecipesRequest
+	err := c.ShouldBindJSON(&req)
 	if err != nil {
-		c.Error(err)
+		httperr.Abort(c, errs.Validation(err))
+		return
 	}
 
-	err = s.app.UpdatedRecipe(c.Copy().Request.Context(), req.EntityID)
+	recipes, missing, err := s.app.GetRecipes(c.Copy().Request.Context(), req.IDs)
 	if err != nil {
-		c.Error(err)
+		httperr.Abort(c, err)
+		return
 	}
+	cacheJSON(c, gin.H{"recipes": recipes, "missing": missing})
 }
 
-// DeletedRecipeRequest.
-type DeletedRecipeRequest struct {
-	Message  string `json:"message"`
-	EntityID string `json:"entity_id"`
+// cacheJSON writes the body as JSON along with its ETag, or just the status
+// not modified if the client has it already.
+func cacheJSON(c *gin.Context, body interface{}) {
+	jb, err := json.Marshal(body)
+	if err != nil {
+		httperr.Abort(c, err)
+		return
+	}
+	sum := sha256.Sum256(jb)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	if etagMatch(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", jb)
 }
 
-// DeletedRecipe listens for IDs of recipes that have been deleted.
-func (s *GospigaService) DeletedRecipe(c *gin.Context) {
-	var req DeletedRecipeRequest
-	err := c.BindJSON(&req)
-	if err != nil {
-		c.Error(err)
+// etagMatch reports whether the If-None-Match header matches the ETag.
+func etagMatch(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == etag || t == "*" {
+			return true
+		}
 	}
+	return false
+}
 
-	err = s.app.DeletedRecipe(c.Copy().Request.Context(), req.EntityID)
+// LoadRecipes initializes the platform loading all the recipes in the
+// background, replying with the job to poll. It is safe to be called
+// multiple times.
+func (s *GospigaService) LoadRecipes(c *gin.Context) {
+	job, err := s.app.LoadRecipes(c.Copy().Request.Context())
 	if err != nil {
-		c.Error(err)
+		httperr.Abort(c, err)
+		return
 	}
+	c.Header("Location", "/server/x/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, gin.H{"job": job})
 }
 
-// LoadRecipes initializes the platform loading all the recipes. It is safe to
-// be called multiple times.
-func (s *GospigaService) LoadRecipes(c *gin.Context) {
-	err := s.app.LoadRecipes(c.Copy().Request.Context())
+// Reconcile sends the events of the changes to the recipes missed on the
+// provider, or only reports them with dry_run=true.
+func (s *GospigaService) Reconcile(c *gin.Context) {
+	dryRun := false
+	if q := c.Query("dry_run"); q != "" {
+		b, err := strconv.ParseBool(q)
+		if err != nil {
+			httperr.Abort(c, errs.Validation(err))
+			return
+		}
+		dryRun = b
+	}
+
+	rec, err := s.app.Reconcile(c.Copy().Request.Context(), dryRun)
 	if err != nil {
-		c.Error(err)
+		httperr.Abort(c, err)
+		return
 	}
-	c.Status(200)
+	c.JSON(http.StatusOK, gin.H{"reconciliation": rec})
 }
@@ -2,10 +2,11 @@ package api
 
 // GospigaService wraps app implementation.
 type GospigaService struct {
-	app App
+	app     App
+	webhook *WebhookArgs
 }
 
 // NewService returns a new instance of GospigaService.
-func NewService(app App) *GospigaService {
-	return &GospigaService{app: app}
+func NewService(app App, webhook *WebhookArgs) *GospigaService {
+	return &GospigaService{app: app, webhook: webhook}
 }
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	errs "gospiga/pkg/errors"
+	"gospiga/pkg/httperr"
+	"gospiga/pkg/streamer"
+)
+
+const defaultDeadLetterCount = 100
+
+// DeadLetters lists the messages dead-lettered from the given stream.
+func (s *GospigaService) DeadLetters(c *gin.Context) {
+	count := int64(defaultDeadLetterCount)
+	if q := c.Query("count"); q != "" {
+		n, err := strconv.ParseInt(q, 10, 64)
+		if err != nil {
+			httperr.Abort(c, errs.Validation(err))
+			return
+		}
+		count = n
+	}
+
+	dls, err := s.app.DeadLetters(c.Copy().Request.Context(), c.Param("stream"), count)
+	if err != nil {
+		httperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deadLetters": dls})
+}
+
+// DeadLetter returns a single dead-lettered message.
+func (s *GospigaService) DeadLetter(c *gin.Context) {
+	dl, err := s.app.DeadLetter(c.Copy().Request.Context(), c.Param("stream"), c.Param("id"))
+	if err != nil {
+		httperr.Abort(c, err)
+		return
+	}
+	if dl == nil {
+		httperr.Abort(c, errs.NotFound(fmt.Errorf("dead letter %q not found", c.Param("id"))))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deadLetter": dl})
+}
+
+// Redrive sends a dead-lettered message back to its original stream.
+func (s *GospigaService) Redrive(c *gin.Context) {
+	id, err := s.app.Redrive(c.Copy().Request.Context(), c.Param("stream"), c.Param("id"))
+	if err != nil {
+		httperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// ConsumerStats returns the stats of the consumer of the recipe streams.
+func (s *GospigaService) ConsumerStats(c *gin.Context) {
+	stats := s.app.ConsumerStats(c.Copy().Request.Context())
+	c.JSON(http.StatusOK, gin.H{"stats": stats, "throughput": stats.Throughput()})
+}
+
+// StreamsInfo reports the state of the streams and of their consumer groups.
+func (s *GospigaService) StreamsInfo(c *gin.Context) {
+	infos, err := s.app.StreamsInfo(c.Copy().Request.Context(), c.QueryArray("stream"))
+	if err != nil {
+		httperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"streams": infos})
+}
+
+// RewindRequest moves a consumer group to an entry ID or back to a point in
+// time.
+type RewindRequest struct {
+	Group string    `json:"group"`
+	ID    string    `json:"id"`
+	Since time.Time `json:"since"`
+}
+
+// groupID returns the ID to move the group to.
+func (r *RewindRequest) groupID() (string, error) {
+	switch {
+	case r.ID != "" && !r.Since.IsZero():
+		return "", errors.New("either id or since must be given, not both")
+	case r.ID != "":
+		return r.ID, nil
+	case !r.Since.IsZero():
+		return streamer.TimeID(r.Since), nil
+	}
+	return "", errors.New("missing id or since")
+}
+
+// Rewind moves a consumer group on the stream, so that the entries after the
+// given position are delivered again.
+func (s *GospigaService) Rewind(c *gin.Context) {
+	var req RewindRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		httperr.Abort(c, errs.Validation(err))
+		return
+	}
+	id, err := req.groupID()
+	if err != nil {
+		httperr.Abort(c, errs.Validation(err))
+		return
+	}
+
+	err = s.app.SetGroupID(c.Copy().Request.Context(), c.Param("stream"), req.Group, id)
+	if err != nil {
+		httperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
@@ -1,13 +1,18 @@
 package api
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+
+	"gospiga/pkg/httperr"
 )
 
 func (s *GospigaService) AllTagsImages(c *gin.Context) {
 	tags, err := s.app.AllTagsImages(c.Copy().Request.Context())
 	if err != nil {
-		c.Error(err)
+		httperr.Abort(c, err)
+		return
 	}
-	c.JSON(200, gin.H{"tags": tags})
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
 }
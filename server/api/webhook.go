@@ -0,0 +1,169 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	errs "gospiga/pkg/errors"
+	"gospiga/pkg/httperr"
+	"gospiga/pkg/log"
+)
+
+const (
+	// secretHeader carries the shared secret of the webhooks.
+	secretHeader = "X-Webhook-Secret"
+	// recipeModel is the API key of the recipe model on DatoCMS.
+	recipeModel = "recipe"
+)
+
+// WebhookArgs configures the webhooks. Requests are accepted with either the
+// basic auth credentials or the shared secret.
+type WebhookArgs struct {
+	// Username and Password expected via HTTP basic auth.
+	Username string
+	Password string
+	// Secret expected in the X-Webhook-Secret header.
+	Secret string
+	// Guard rejects the replayed events, nil accepts all of them.
+	Guard ReplayGuard
+}
+
+// authorized reports whether the request carries the expected credentials.
+func (w *WebhookArgs) authorized(r *http.Request) bool {
+	if w.Secret != "" {
+		secret := r.Header.Get(secretHeader)
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(w.Secret)) == 1 {
+			return true
+		}
+	}
+	if w.Username != "" {
+		user, pass, ok := r.BasicAuth()
+		if ok &&
+			subtle.ConstantTimeCompare([]byte(user), []byte(w.Username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(pass), []byte(w.Password)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// DatoWebhook is the payload of the DatoCMS webhooks.
+type DatoWebhook struct {
+	Environment     string       `json:"environment"`
+	EntityType      string       `json:"entity_type"`
+	EventType       string       `json:"event_type"`
+	Entity          DatoEntity   `json:"entity"`
+	RelatedEntities []DatoEntity `json:"related_entities"`
+}
+
+// DatoEntity is an entity of the DatoCMS API.
+type DatoEntity struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Attributes struct {
+		APIKey string `json:"api_key"`
+	} `json:"attributes"`
+	Relationships struct {
+		ItemType struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		} `json:"item_type"`
+	} `json:"relationships"`
+	Meta struct {
+		UpdatedAt *time.Time `json:"updated_at"`
+	} `json:"meta"`
+}
+
+// model returns the API key of the model of the item, if sent along.
+func (w *DatoWebhook) model() string {
+	id := w.Entity.Relationships.ItemType.Data.ID
+	for _, e := range w.RelatedEntities {
+		if e.Type == "item_type" && e.ID == id {
+			return e.Attributes.APIKey
+		}
+	}
+	return ""
+}
+
+// eventID identifies the event, the same change sent again gets the same ID.
+func (w *DatoWebhook) eventID() string {
+	return fmt.Sprintf("%s:%s:%s:%d", w.Environment, w.Entity.ID, w.EventType, w.Entity.Meta.UpdatedAt.UnixNano())
+}
+
+// DatoWebhook listens for the changes to the recipes on DatoCMS.
+func (s *GospigaService) DatoWebhook(c *gin.Context) {
+	if !s.webhook.authorized(c.Request) {
+		httperr.Abort(c, errs.Unauthorized(errors.New("invalid webhook credentials")))
+		return
+	}
+
+	var w DatoWebhook
+	err := c.ShouldBindJSON(&w)
+	if err != nil {
+		httperr.Abort(c, errs.Validation(err))
+		return
+	}
+	if w.Entity.ID == "" || w.Entity.Meta.UpdatedAt == nil {
+		httperr.Abort(c, errs.Validation(errors.New("missing entity ID or timestamp")))
+		return
+	}
+	if w.EntityType != "item" {
+		log.Debugf("ignoring %s event on %s %q", w.EventType, w.EntityType, w.Entity.ID)
+		c.Status(http.StatusOK)
+		return
+	}
+	if m := w.model(); m != "" && m != recipeModel {
+		log.Debugf("ignoring %s event on %s %q", w.EventType, m, w.Entity.ID)
+		c.Status(http.StatusOK)
+		return
+	}
+
+	ctx := c.Request.Context()
+	var notify func() error
+	switch w.EventType {
+	case "create":
+		notify = func() error { return s.app.NewRecipe(ctx, w.Entity.ID) }
+	case "update":
+		notify = func() error { return s.app.UpdatedRecipe(ctx, w.Entity.ID) }
+	case "publish":
+		notify = func() error { return s.app.PublishedRecipe(ctx, w.Entity.ID) }
+	case "delete", "unpublish":
+		notify = func() error { return s.app.DeletedRecipe(ctx, w.Entity.ID) }
+	default:
+		httperr.Abort(c, errs.Validation(fmt.Errorf("unknown event type %q", w.EventType)))
+		return
+	}
+
+	guard := s.webhook.Guard
+	if guard != nil {
+		ok, err := guard.Accept(ctx, w.eventID(), w.Entity.ID, *w.Entity.Meta.UpdatedAt)
+		if err != nil {
+			httperr.Abort(c, err)
+			return
+		}
+		if !ok {
+			httperr.Abort(c, errs.Conflict(fmt.Errorf("replayed %s event on recipe ID %q", w.EventType, w.Entity.ID)))
+			return
+		}
+	}
+
+	err = notify()
+	if err != nil {
+		if guard != nil {
+			// accept it when sent again
+			ferr := guard.Forget(ctx, w.eventID())
+			if ferr != nil {
+				log.Errorf("error forgetting event of recipe ID %q: %s", w.Entity.ID, ferr)
+			}
+		}
+		httperr.Abort(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
@@ -10,6 +10,7 @@ import (
 	"runtime/debug"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -18,19 +19,27 @@ import (
 	"google.golang.org/grpc"
 
 	"gospiga"
+	"gospiga/pkg/auth"
+	"gospiga/pkg/httperr"
 	"gospiga/pkg/log"
+	"gospiga/pkg/nats"
 	"gospiga/pkg/provider"
 	"gospiga/pkg/redis"
 	"gospiga/pkg/streamer"
 	pb "gospiga/proto"
 	"gospiga/server/api"
 	"gospiga/server/db/dgraph"
+	redisdb "gospiga/server/db/redis"
 	"gospiga/server/domain"
+	"gospiga/server/gql"
 	gogrpc "gospiga/server/grpc"
 	"gospiga/server/usecase"
 )
 
-const defaultFinderPort = "50051"
+const (
+	defaultFinderPort = "50051"
+	producerTimeout   = 2 * time.Second
+)
 
 func init() {
 	viper.SetConfigName("config")
@@ -78,9 +87,26 @@ func main() {
 	}
 
 	ds := domain.NewService(db)
-	streamer, err := streamer.NewRedisStreamer(rdb)
-	if err != nil {
-		log.Fatalf("error initializing redis streamer: %s", err)
+	var st usecase.Streamer
+	switch viper.GetString("streamer.backend") {
+	case "memory":
+		log.Infof("using in-memory streamer, messages won't survive restarts")
+		st = streamer.NewMemoryStreamer()
+	case "nats":
+		nc, err := nats.NewConn(viper.GetString("streamer.nats.url"))
+		if err != nil {
+			log.Fatalf("can't connect to nats: %s", err)
+		}
+		defer nc.Close()
+		st, err = streamer.NewNatsStreamer(nc)
+		if err != nil {
+			log.Fatalf("error initializing nats streamer: %s", err)
+		}
+	default:
+		st, err = streamer.NewRedisStreamer(rdb)
+		if err != nil {
+			log.Fatalf("error initializing redis streamer: %s", err)
+		}
 	}
 
 	token := viper.GetString("dato.token")
@@ -105,8 +131,48 @@ func main() {
 	grpcClient := pb.NewFinderClient(conn)
 	stub := gogrpc.NewStub(&grpcClient)
 
-	app := usecase.NewApp(ds, db, streamer, provider, stub)
-	service := api.NewService(app)
+	producer, err := streamer.NewProducer(ctx, st, &streamer.ProducerArgs{
+		Timeout: producerTimeout,
+		Breaker: &streamer.Breaker{Threshold: 5, Cooldown: 10 * time.Second},
+		// keep the webhook events while the streamer is down
+		SpoolDir: viper.GetString("streamer.spool"),
+	})
+	if err != nil {
+		log.Fatalf("error initializing producer: %s", err)
+	}
+
+	jobs, err := redisdb.NewJobStore(rdb)
+	if err != nil {
+		log.Fatalf("error initializing job store: %s", err)
+	}
+
+	app := usecase.NewApp(ds, db, st, producer, jobs, provider, stub, viper.GetInt("streamer.concurrency"))
+	guard, err := redisdb.NewReplayGuard(rdb)
+	if err != nil {
+		log.Fatalf("error initializing webhook replay guard: %s", err)
+	}
+	webhook := &api.WebhookArgs{
+		Username: viper.GetString("dato.webhook.username"),
+		Password: viper.GetString("dato.webhook.password"),
+		Secret:   viper.GetString("dato.webhook.secret"),
+		Guard:    guard,
+	}
+	if webhook.Username == "" && webhook.Secret == "" {
+		log.Warnf("missing dato webhook credentials, webhooks will be rejected")
+	}
+	admin := &auth.Credentials{
+		Username: viper.GetString("admin.username"),
+		Password: viper.GetString("admin.password"),
+		Token:    viper.GetString("admin.token"),
+	}
+	if admin.Empty() {
+		log.Warnf("missing admin credentials, admin requests will be rejected")
+	}
+	service := api.NewService(app, webhook)
+	graph, err := gql.NewHandler(db)
+	if err != nil {
+		log.Fatalf("error initializing graphql api: %s", err)
+	}
 
 	config := cors.DefaultConfig()
 	config.AddAllowHeaders("X-Apollo-Tracing")
@@ -114,23 +180,38 @@ func main() {
 	c := cors.New(config)
 
 	r := gin.Default()
-	r.Use(c)
+	r.Use(c, httperr.Handler())
 	r.LoadHTMLFiles("/templates/graphql-playground.html")
 	g := r.Group("/server")
 	{
 		g.Group("/server")
 		g.GET("/ping", service.Ping)
+		g.POST("/graphql", graph.Serve)
 		g.GET("/x/gql/play", func(c *gin.Context) {
 			c.HTML(http.StatusOK, "graphql-playground.html", gin.H{
 				"title": "GraphQL Playground",
 			})
 		})
-		g.POST("/new-recipe", service.NewRecipe)
-		g.POST("/updated-recipe", service.UpdatedRecipe)
-		g.POST("/deleted-recipe", service.DeletedRecipe)
+		g.POST("/webhooks/dato", service.DatoWebhook)
+		g.GET("/recipes/:xid", service.GetRecipe)
+		// i.e. /recipes/by-slug/:slug, the router can't tell it from :xid
+		g.GET("/recipes/:xid/:slug", service.GetRecipeBySlug)
+		g.POST("/recipes/batch", service.GetRecipes)
 		g.POST("/all-tags-images", service.AllTagsImages)
 		g.POST("/load-recipes", service.LoadRecipes)
 	}
+	x := g.Group("/x", auth.Required(admin))
+	{
+		x.GET("/dlq/:stream", service.DeadLetters)
+		x.GET("/dlq/:stream/:id", service.DeadLetter)
+		x.POST("/dlq/:stream/:id/redrive", service.Redrive)
+		x.GET("/consumer/stats", service.ConsumerStats)
+		x.GET("/streams", service.StreamsInfo)
+		x.POST("/streams/:stream/rewind", service.Rewind)
+		x.POST("/reconcile", service.Reconcile)
+		x.GET("/jobs/:id", service.GetJob)
+		x.POST("/jobs/:id/cancel", service.CancelJob)
+	}
 	go r.Run()
 
 	// wait for shutdown
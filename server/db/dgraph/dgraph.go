@@ -8,6 +8,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dgraph-io/dgo/v2"
@@ -15,6 +17,7 @@ import (
 
 	"google.golang.org/grpc"
 
+	"gospiga/pkg/errors"
 	"gospiga/pkg/log"
 )
 
@@ -113,3 +116,33 @@ func (db *DB) count(ctx context.Context, onType string) (int, error) {
 	}
 	return root.CountType[0].TotalCount, nil
 }
+
+// pageArgs returns the arguments to get up to first nodes, in uid order,
+// starting after the given uid if any.
+func pageArgs(first int, after string) (string, error) {
+	if after == "" {
+		return fmt.Sprintf("first: %d", first), nil
+	}
+	// the uid is inlined, being not allowed as query variable
+	_, err := strconv.ParseUint(strings.TrimPrefix(after, "0x"), 16, 64)
+	if err != nil || !strings.HasPrefix(after, "0x") {
+		return "", errors.Validation(fmt.Errorf("invalid uid %q", after))
+	}
+	return fmt.Sprintf("first: %d, after: %s", first, after), nil
+}
+
+// listVars returns the declaration of one string variable for each of the
+// values, named after prefix, the list of them to use in the query and their
+// values, so that the values are never pasted into the query.
+func listVars(prefix string, values []string) (string, string, map[string]string) {
+	decls := make([]string, 0, len(values))
+	names := make([]string, 0, len(values))
+	vars := make(map[string]string, len(values))
+	for i, v := range values {
+		name := fmt.Sprintf("$%s%d", prefix, i)
+		decls = append(decls, name+": string")
+		names = append(names, name)
+		vars[name] = v
+	}
+	return strings.Join(decls, ", "), "[" + strings.Join(names, ", ") + "]", vars
+}
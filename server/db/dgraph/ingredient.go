@@ -1,6 +1,7 @@
 package dgraph
 
 import (
+	"context"
 	"encoding/json"
 
 	"gospiga/pkg/stemmer"
@@ -62,3 +63,103 @@ func (i *Ingredient) ToDomain() *domain.Ingredient {
 		UnitOfMeasure: i.UnitOfMeasure,
 	}
 }
+
+// ToDomain converts a dgraph food into a domain food.
+func (f *Food) ToDomain() *domain.Food {
+	return &domain.Food{
+		ID:   f.ID,
+		Term: f.Term,
+	}
+}
+
+// GetFoods returns up to first foods, in uid order, starting after the given
+// uid if any.
+func (db *DB) GetFoods(ctx context.Context, first int, after string) ([]*domain.Food, error) {
+	page, err := pageArgs(first, after)
+	if err != nil {
+		return nil, err
+	}
+	q := `
+		{
+			foods(func: type(Food), ` + page + `) {
+				uid
+				term
+			}
+		}
+	`
+
+	resp, err := db.Dgraph.NewReadOnlyTxn().Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var root struct {
+		Foods []Food `json:"foods"`
+	}
+	err = json.Unmarshal(resp.Json, &root)
+	if err != nil {
+		return nil, err
+	}
+
+	foods := make([]*domain.Food, 0, len(root.Foods))
+	for _, f := range root.Foods {
+		foods = append(foods, f.ToDomain())
+	}
+	return foods, nil
+}
+
+// FoodsRecipeUIDs returns the uids of the recipes using each of the given
+// foods as ingredient.
+func (db *DB) FoodsRecipeUIDs(ctx context.Context, terms []string) (map[string][]string, error) {
+	if len(terms) == 0 {
+		return map[string][]string{}, nil
+	}
+	byStem, err := stems(terms)
+	if err != nil {
+		return nil, err
+	}
+	decl, list, vars := listVars("food", keys(byStem))
+	q := `
+		query Foods(` + decl + `){
+			foods(func: eq(stem, ` + list + `)) {
+				stem
+				ingredient: ~food {
+					recipe: ~ingredients {
+						uid
+					}
+				}
+			}
+		}
+	`
+
+	resp, err := db.Dgraph.NewReadOnlyTxn().QueryWithVars(ctx, q, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var root struct {
+		Foods []Food `json:"foods"`
+	}
+	err = json.Unmarshal(resp.Json, &root)
+	if err != nil {
+		return nil, err
+	}
+
+	uids := make(map[string][]string, len(terms))
+	for _, f := range root.Foods {
+		// the same food can be used more than once by a recipe
+		seen := make(map[string]bool)
+		for _, i := range f.Ingredients {
+			for _, r := range i.Recipes {
+				if seen[r.ID] {
+					continue
+				}
+				seen[r.ID] = true
+				for _, term := range byStem[f.Stem] {
+					uids[term] = append(uids[term], r.ID)
+				}
+			}
+		}
+	}
+	return uids, nil
+}
@@ -0,0 +1,176 @@
+package dgraph
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/dgo/v2/protos/api"
+
+	"gospiga/server/domain"
+)
+
+// Outbox represents a change to a recipe waiting to be published. Entries are
+// written in the same transaction as the change and deleted once delivered.
+type Outbox struct {
+	ID        string          `json:"uid,omitempty"`
+	EventID   string          `json:"outboxEventID,omitempty"`
+	Op        domain.OutboxOp `json:"outboxOp,omitempty"`
+	RecipeID  string          `json:"outboxRecipeID,omitempty"`
+	Recipe    *Recipe         `json:"outboxRecipe,omitempty"`
+	CreatedAt *time.Time      `json:"outboxCreatedAt,omitempty"`
+	DType     []string        `json:"dgraph.type,omitempty"`
+}
+
+func (o Outbox) MarshalJSON() ([]byte, error) {
+	type Alias Outbox
+	if len(o.DType) == 0 {
+		o.DType = []string{"Outbox"}
+	}
+	return json.Marshal((Alias)(o))
+}
+
+// ToDomain converts a dgraph outbox entry into a domain outbox entry.
+func (o *Outbox) ToDomain() *domain.OutboxEntry {
+	e := &domain.OutboxEntry{
+		ID:       o.ID,
+		EventID:  o.EventID,
+		Op:       o.Op,
+		RecipeID: o.RecipeID,
+	}
+	if o.CreatedAt != nil {
+		e.CreatedAt = *o.CreatedAt
+	}
+	// a recipe deleted in the meantime has no fields left
+	if o.Recipe != nil && o.Recipe.ExternalID != "" {
+		e.Recipe = o.Recipe.ToDomain()
+	}
+	return e
+}
+
+// outboxMutation returns the mutation writing an outbox entry for the change
+// to the recipe, applied under the same condition as the change. The recipe
+// is referenced by uid, so that the entry is published with the recipe as
+// stored.
+func outboxMutation(op domain.OutboxOp, recipeID, recipeRef, cond string) (*api.Mutation, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	o := Outbox{
+		ID:        "_:outbox",
+		EventID:   hex.EncodeToString(id),
+		Op:        op,
+		RecipeID:  recipeID,
+		CreatedAt: &now,
+	}
+	if recipeRef != "" {
+		o.Recipe = &Recipe{ID: recipeRef}
+	}
+	jo, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return &api.Mutation{
+		SetJson: jo,
+		Cond:    cond,
+	}, nil
+}
+
+// PendingOutbox returns up to n outbox entries, oldest first.
+func (db *DB) PendingOutbox(ctx context.Context, n int) ([]*domain.OutboxEntry, error) {
+	vars := map[string]string{"$n": fmt.Sprint(n)}
+	q := `
+		query Pending($n: int){
+			outbox(func: type(Outbox), orderasc: outboxCreatedAt, first: $n) {
+				uid
+				outboxEventID
+				outboxOp
+				outboxRecipeID
+				outboxCreatedAt
+				outboxRecipe {
+					uid
+					xid
+					title
+					subtitle
+					mainImage
+					likes
+					difficulty
+					cost
+					prepTime
+					cookTime
+					servings
+					extraNotes
+					description
+					ingredients {
+						uid
+						name
+						quantity
+						unitOfMeasure
+					}
+					steps {
+						uid
+						heading
+						body
+						image
+					}
+					tags {
+						uid
+						tagName
+					}
+					conclusion
+					slug
+					createdAt
+					modifiedAt
+				}
+			}
+		}
+	`
+
+	resp, err := db.Dgraph.NewReadOnlyTxn().QueryWithVars(ctx, q, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var root struct {
+		Outbox []Outbox `json:"outbox"`
+	}
+	err = json.Unmarshal(resp.Json, &root)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*domain.OutboxEntry, 0, len(root.Outbox))
+	for _, o := range root.Outbox {
+		entries = append(entries, o.ToDomain())
+	}
+	return entries, nil
+}
+
+// DeleteOutbox deletes the outbox entries with the given uids.
+func (db *DB) DeleteOutbox(ctx context.Context, uids []string) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	d := make([]map[string]string, 0, len(uids))
+	for _, uid := range uids {
+		d = append(d, map[string]string{"uid": uid})
+	}
+
+	pb, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	req := &api.Request{CommitNow: true}
+	req.Mutations = []*api.Mutation{{DeleteJson: pb}}
+
+	_, err = db.Dgraph.NewTxn().Do(ctx, req)
+
+	return err
+}
@@ -224,7 +224,7 @@ func (db *DB) SaveRecipe(ctx context.Context, dr *domain.Recipe) error {
 	req.Vars = map[string]string{"$xid": dr.ExternalID}
 	req.Query = sb.String()
 
-	mutations := make([]*api.Mutation, 0, len(dr.Ingredients)*2+len(dr.Tags)*2+1)
+	mutations := make([]*api.Mutation, 0, len(dr.Ingredients)*2+len(dr.Tags)*2+2)
 
 	// keep any food and tag
 	for i, di := range dr.Ingredients {
@@ -326,7 +326,11 @@ func (db *DB) SaveRecipe(ctx context.Context, dr *domain.Recipe) error {
 		SetJson: jr,
 		Cond:    "@if(eq(len(r), 0))",
 	}
-	mutations = append(mutations, mu)
+	mo, err := outboxMutation(domain.OutboxSave, dr.ExternalID, "_:recipe", mu.Cond)
+	if err != nil {
+		return err
+	}
+	mutations = append(mutations, mu, mo)
 
 	req.Mutations = mutations
 
@@ -366,7 +370,7 @@ func (db *DB) UpdateRecipe(ctx context.Context, dr *domain.Recipe) (string, erro
 	req.Vars = map[string]string{"$xid": dr.ExternalID}
 	req.Query = sb.String()
 
-	mutations := make([]*api.Mutation, 0, len(dr.Ingredients)*2+len(dr.Tags)*2+2)
+	mutations := make([]*api.Mutation, 0, len(dr.Ingredients)*2+len(dr.Tags)*2+3)
 
 	// remove old edges
 	rdel := map[string]interface{}{
@@ -485,7 +489,11 @@ func (db *DB) UpdateRecipe(ctx context.Context, dr *domain.Recipe) (string, erro
 		SetJson: jr,
 		Cond:    "@if(eq(len(r), 1))",
 	}
-	mutations = append(mutations, mu)
+	mo, err := outboxMutation(domain.OutboxSave, dr.ExternalID, "uid(r)", mu.Cond)
+	if err != nil {
+		return "", err
+	}
+	mutations = append(mutations, mu, mo)
 
 	req.Mutations = mutations
 
@@ -540,8 +548,12 @@ func (db *DB) DeleteRecipe(ctx context.Context, recipeID string) error {
 	mu := &api.Mutation{
 		DeleteJson: pb,
 	}
+	mo, err := outboxMutation(domain.OutboxDelete, recipeID, "", "")
+	if err != nil {
+		return err
+	}
 	req := &api.Request{CommitNow: true}
-	req.Mutations = []*api.Mutation{mu}
+	req.Mutations = []*api.Mutation{mu, mo}
 
 	_, err = db.Dgraph.NewTxn().Do(ctx, req)
 
@@ -632,6 +644,156 @@ func (db *DB) getRecipeByID(ctx context.Context, id string) (*Recipe, error) {
 	return &root.Recipes[0], nil
 }
 
+// GetRecipeBySlug and return a domain recipe, nil if not found.
+func (db *DB) GetRecipeBySlug(ctx context.Context, slug string) (*domain.Recipe, error) {
+	vars := map[string]string{"$slug": slug}
+	q := `
+		query Recipes($slug: string){
+			recipes(func: eq(slug, $slug), first: 1) {
+				uid
+				xid
+				title
+				subtitle
+				mainImage {
+					uid
+					url
+				}
+				likes
+				difficulty
+				cost
+				prepTime
+				cookTime
+				servings
+				extraNotes
+				description
+				ingredients {
+					uid
+					name
+					quantity
+					unitOfMeasure
+					food {
+						uid
+						term
+						stem
+					}
+				}
+				steps {
+					uid
+					heading
+					body
+					image {
+						uid
+						url
+					}
+				}
+				tags {
+					uid
+					tagName
+				}
+				conclusion
+				slug
+				createdAt
+				modifiedAt
+			}
+		}
+	`
+
+	resp, err := db.Dgraph.NewReadOnlyTxn().QueryWithVars(ctx, q, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var root struct {
+		Recipes []Recipe `json:"recipes"`
+	}
+	err = json.Unmarshal(resp.Json, &root)
+	if err != nil {
+		return nil, err
+	}
+	if len(root.Recipes) == 0 {
+		return nil, nil
+	}
+	return root.Recipes[0].ToDomain(), nil
+}
+
+// GetRecipesByXIDs and return domain recipes, the ones not found are left
+// out.
+func (db *DB) GetRecipesByXIDs(ctx context.Context, xids []string) ([]*domain.Recipe, error) {
+	if len(xids) == 0 {
+		return nil, nil
+	}
+	decl, list, vars := listVars("xid", xids)
+	q := `
+		query Recipes(` + decl + `){
+			recipes(func: eq(xid, ` + list + `)) {
+				uid
+				xid
+				title
+				subtitle
+				mainImage {
+					uid
+					url
+				}
+				likes
+				difficulty
+				cost
+				prepTime
+				cookTime
+				servings
+				extraNotes
+				description
+				ingredients {
+					uid
+					name
+					quantity
+					unitOfMeasure
+					food {
+						uid
+						term
+						stem
+					}
+				}
+				steps {
+					uid
+					heading
+					body
+					image {
+						uid
+						url
+					}
+				}
+				tags {
+					uid
+					tagName
+				}
+				conclusion
+				slug
+				createdAt
+				modifiedAt
+			}
+		}
+	`
+
+	resp, err := db.Dgraph.NewReadOnlyTxn().QueryWithVars(ctx, q, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var root struct {
+		Recipes []Recipe `json:"recipes"`
+	}
+	err = json.Unmarshal(resp.Json, &root)
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := make([]*domain.Recipe, 0, len(root.Recipes))
+	for _, r := range root.Recipes {
+		recipes = append(recipes, r.ToDomain())
+	}
+	return recipes, nil
+}
+
 // GetRecipesByUIDs and return domain recipes.
 func (db *DB) GetRecipesByUIDs(ctx context.Context, uids []string) ([]*domain.Recipe, error) {
 	uu := strings.Join(uids, ", ")
@@ -710,6 +872,80 @@ func (db *DB) GetRecipesByUIDs(ctx context.Context, uids []string) ([]*domain.Re
 	return recipes, nil
 }
 
+// RecipeUIDs returns the uids of up to first recipes, in uid order, starting
+// after the given uid if any.
+func (db *DB) RecipeUIDs(ctx context.Context, first int, after string) ([]string, error) {
+	page, err := pageArgs(first, after)
+	if err != nil {
+		return nil, err
+	}
+	q := `
+		{
+			recipes(func: type(Recipe), ` + page + `) {
+				uid
+			}
+		}
+	`
+
+	resp, err := db.Dgraph.NewReadOnlyTxn().Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var root struct {
+		Recipes []Recipe `json:"recipes"`
+	}
+	err = json.Unmarshal(resp.Json, &root)
+	if err != nil {
+		return nil, err
+	}
+
+	uids := make([]string, 0, len(root.Recipes))
+	for _, r := range root.Recipes {
+		uids = append(uids, r.ID)
+	}
+	return uids, nil
+}
+
+// GetRecipeVersions returns the time each recipe stored was last modified,
+// keyed by external ID.
+func (db *DB) GetRecipeVersions(ctx context.Context) (map[string]time.Time, error) {
+	q := `
+		{
+			recipes(func: type(Recipe)) {
+				xid
+				modifiedAt
+			}
+		}
+	`
+
+	resp, err := db.Dgraph.NewReadOnlyTxn().Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var root struct {
+		Recipes []Recipe `json:"recipes"`
+	}
+	err = json.Unmarshal(resp.Json, &root)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]time.Time, len(root.Recipes))
+	for _, r := range root.Recipes {
+		if r.ExternalID == "" {
+			continue
+		}
+		var t time.Time
+		if r.ModifiedAt != nil {
+			t = *r.ModifiedAt
+		}
+		versions[r.ExternalID] = t
+	}
+	return versions, nil
+}
+
 // IDSaved check if the given external ID is stored.
 func (db *DB) IDSaved(ctx context.Context, id string) (bool, error) {
 	vars := map[string]string{"$id": id}
@@ -789,6 +1025,14 @@ func loadRecipeSchema() *api.Operation {
 			<~tags>
 		}
 
+		type Outbox {
+			outboxEventID
+			outboxOp
+			outboxRecipeID
+			outboxRecipe
+			outboxCreatedAt
+		}
+
 		xid: string @index(hash) .
 		title: string @lang @index(fulltext) .
 		subtitle: string @lang @index(fulltext) .
@@ -820,7 +1064,12 @@ func loadRecipeSchema() *api.Operation {
 		modifiedAt: dateTime @index(hour) @upsert .
 		tagName: string @index(fulltext) .
 		tagStem: string @index(hash) .
-		slug: string .
+		slug: string @index(exact) .
+		outboxEventID: string .
+		outboxOp: string .
+		outboxRecipeID: string .
+		outboxRecipe: uid .
+		outboxCreatedAt: dateTime @index(hour) .
 	`
 	return op
 }
@@ -91,3 +91,99 @@ func (db *DB) AllTagsImages(ctx context.Context) ([]*domain.Tag, error) {
 	}
 	return tags, nil
 }
+
+// GetTags returns all the tags stored on db, sorted by name.
+func (db *DB) GetTags(ctx context.Context) ([]*domain.Tag, error) {
+	q := `
+		{
+			tags(func: type(Tag), orderasc: tagName) {
+				tagName
+			}
+		}
+	`
+
+	resp, err := db.Dgraph.NewReadOnlyTxn().Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var root struct {
+		Tags []Tag `json:"tags"`
+	}
+	err = json.Unmarshal(resp.Json, &root)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]*domain.Tag, 0, len(root.Tags))
+	for _, t := range root.Tags {
+		tags = append(tags, &domain.Tag{TagName: t.TagName})
+	}
+	return tags, nil
+}
+
+// TagsRecipeUIDs returns the uids of the recipes of each of the given tags.
+func (db *DB) TagsRecipeUIDs(ctx context.Context, names []string) (map[string][]string, error) {
+	if len(names) == 0 {
+		return map[string][]string{}, nil
+	}
+	byStem, err := stems(names)
+	if err != nil {
+		return nil, err
+	}
+	decl, list, vars := listVars("tag", keys(byStem))
+	q := `
+		query Tags(` + decl + `){
+			tags(func: eq(tagStem, ` + list + `)) {
+				tagStem
+				recipes: ~tags {
+					uid
+				}
+			}
+		}
+	`
+
+	resp, err := db.Dgraph.NewReadOnlyTxn().QueryWithVars(ctx, q, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var root struct {
+		Tags []Tag `json:"tags"`
+	}
+	err = json.Unmarshal(resp.Json, &root)
+	if err != nil {
+		return nil, err
+	}
+
+	uids := make(map[string][]string, len(names))
+	for _, t := range root.Tags {
+		for _, r := range t.Recipes {
+			for _, name := range byStem[t.TagStem] {
+				uids[name] = append(uids[name], r.ID)
+			}
+		}
+	}
+	return uids, nil
+}
+
+// stems groups the given terms by their stem.
+func stems(terms []string) (map[string][]string, error) {
+	byStem := make(map[string][]string, len(terms))
+	for _, t := range terms {
+		s, err := stemmer.Stem(t, "italian")
+		if err != nil {
+			return nil, err
+		}
+		byStem[s] = append(byStem[s], t)
+	}
+	return byStem, nil
+}
+
+func keys(m map[string][]string) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	return ks
+}
@@ -0,0 +1,197 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v7"
+
+	errs "gospiga/pkg/errors"
+	"gospiga/server/domain"
+)
+
+const (
+	// jobTTL is how long the finished jobs are kept.
+	jobTTL = 7 * 24 * time.Hour
+	// lockTTL is how long the job running holds the lock without making any
+	// progress, e.g. if the process loading it dies. The next job fails it
+	// as stalled afterwards.
+	lockTTL = 30 * time.Minute
+	// runningJobKey holds the ID of the job running, if any.
+	runningJobKey = "jobs:running"
+	// latestJobKey holds the ID of the last job created, to tell if it stalled.
+	latestJobKey = "jobs:latest"
+)
+
+// jobStore records the jobs loading the recipes in redis, along with the
+// state of each recipe.
+type jobStore struct {
+	rdb       *goredis.Client
+	createLua string
+	itemsLua  string
+	finishLua string
+}
+
+// NewJobStore returns an instance of jobStore.
+func NewJobStore(client *goredis.Client) (*jobStore, error) {
+	s := &jobStore{rdb: client}
+	var err error
+	s.createLua, err = loadScript(client, "/scripts/lua/createJob.lua")
+	if err != nil {
+		return nil, err
+	}
+	s.itemsLua, err = loadScript(client, "/scripts/lua/setJobItems.lua")
+	if err != nil {
+		return nil, err
+	}
+	s.finishLua, err = loadScript(client, "/scripts/lua/finishJob.lua")
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func jobKey(id string) string {
+	return fmt.Sprintf("job:%s", id)
+}
+
+func jobItemsKey(id string) string {
+	return fmt.Sprintf("job:%s:items", id)
+}
+
+func jobKeys(id string) []string {
+	return []string{jobKey(id), jobItemsKey(id), runningJobKey}
+}
+
+func millis(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+}
+
+func ttl(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10)
+}
+
+// CreateJob records a new running job, failing with a conflict error while
+// another one is running. The last job is failed if it stalled.
+func (s *jobStore) CreateJob(ctx context.Context, id string, createdAt time.Time) error {
+	rdb := s.rdb.WithContext(ctx)
+	latest, err := rdb.Get(latestJobKey).Result()
+	if err != nil && err != goredis.Nil {
+		return err
+	}
+	if latest == "" {
+		latest = id
+	}
+
+	// run pre-loaded script
+	n, err := rdb.EvalSha(
+		s.createLua,
+		[]string{runningJobKey, jobKey(id), latestJobKey, jobKey(latest), jobItemsKey(latest)}, // KEYS
+		[]string{id, millis(createdAt), ttl(lockTTL), ttl(jobTTL)},                             // ARGV
+	).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errs.Conflict(errors.New("another job is running"))
+	}
+	return nil
+}
+
+// AddItems records the recipes to load as queued.
+func (s *jobStore) AddItems(ctx context.Context, id string, items []string) error {
+	pipe := s.rdb.WithContext(ctx).TxPipeline()
+	if len(items) > 0 {
+		values := make(map[string]interface{}, len(items))
+		for _, item := range items {
+			values[item] = string(domain.ItemQueued)
+		}
+		pipe.HSet(jobItemsKey(id), values)
+	}
+	pipe.HSet(jobKey(id), "total", len(items), "queued", len(items))
+	_, err := pipe.Exec()
+	return err
+}
+
+// SetItems moves the queued recipes of the job to the given state, returning
+// the no. of recipes moved. The lock of the job is renewed meanwhile.
+func (s *jobStore) SetItems(ctx context.Context, id string, state domain.ItemState, items ...string) (int, error) {
+	argv := make([]string, 0, len(items)+4)
+	argv = append(argv, string(state), millis(time.Now()), ttl(jobTTL), ttl(lockTTL))
+	argv = append(argv, items...)
+	// run pre-loaded script
+	return s.rdb.WithContext(ctx).EvalSha(
+		s.itemsLua,
+		jobKeys(id), // KEYS
+		argv,        // ARGV
+	).Int()
+}
+
+// SetLoaded records the no. of recipes sent over the stream, the job is done
+// once no recipe is left queued.
+func (s *jobStore) SetLoaded(ctx context.Context, id string, enqueued int) error {
+	err := s.rdb.WithContext(ctx).HSet(jobKey(id), "enqueued", enqueued, "loaded", 1).Err()
+	if err != nil {
+		return err
+	}
+	// the recipes may be all handled already
+	_, err = s.SetItems(ctx, id, domain.ItemQueued)
+	return err
+}
+
+// FinishJob moves the job to the given state, reporting false if it was not
+// running.
+func (s *jobStore) FinishJob(ctx context.Context, id string, state domain.JobState, reason string) (bool, error) {
+	// run pre-loaded script
+	n, err := s.rdb.WithContext(ctx).EvalSha(
+		s.finishLua,
+		jobKeys(id), // KEYS
+		[]string{string(state), millis(time.Now()), ttl(jobTTL), reason}, // ARGV
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// GetJob returns the job, nil if not found.
+func (s *jobStore) GetJob(ctx context.Context, id string) (*domain.Job, error) {
+	h, err := s.rdb.WithContext(ctx).HGetAll(jobKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(h) == 0 {
+		return nil, nil
+	}
+
+	atoi := func(field string) int {
+		n, _ := strconv.Atoi(h[field])
+		return n
+	}
+	job := &domain.Job{
+		ID:        h["id"],
+		State:     domain.JobState(h["state"]),
+		Total:     atoi("total"),
+		Enqueued:  atoi("enqueued"),
+		Queued:    atoi(string(domain.ItemQueued)),
+		Saved:     atoi(string(domain.ItemSaved)),
+		Failed:    atoi(string(domain.ItemFailed)),
+		Skipped:   atoi(string(domain.ItemSkipped)),
+		Cancelled: atoi(string(domain.ItemCancelled)),
+		Error:     h["error"],
+		CreatedAt: fromMillis(h["createdAt"]),
+	}
+	if h["finishedAt"] != "" {
+		t := fromMillis(h["finishedAt"])
+		job.FinishedAt = &t
+	}
+	return job, nil
+}
+
+func fromMillis(ms string) time.Time {
+	n, _ := strconv.ParseInt(ms, 10, 64)
+	return time.Unix(0, n*int64(time.Millisecond)).UTC()
+}
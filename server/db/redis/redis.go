@@ -0,0 +1,16 @@
+package redis
+
+import (
+	"io/ioutil"
+
+	goredis "github.com/go-redis/redis/v7"
+)
+
+// loadScript loads the Lua script at path, returning its SHA.
+func loadScript(client *goredis.Client, path string) (string, error) {
+	script, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return client.ScriptLoad(string(script)).Result()
+}
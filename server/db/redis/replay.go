@@ -0,0 +1,59 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v7"
+)
+
+// eventTTL is how long the webhook events received, and the last one of each
+// entity, are remembered.
+const eventTTL = 7 * 24 * time.Hour
+
+// replayGuard records the webhook events received in redis.
+type replayGuard struct {
+	rdb       *goredis.Client
+	acceptLua string
+}
+
+// NewReplayGuard returns an instance of replayGuard.
+func NewReplayGuard(client *goredis.Client) (*replayGuard, error) {
+	sha, err := loadScript(client, "/scripts/lua/acceptWebhook.lua")
+	if err != nil {
+		return nil, err
+	}
+	return &replayGuard{rdb: client, acceptLua: sha}, nil
+}
+
+func eventKey(id string) string {
+	return fmt.Sprintf("webhook:event:%s", id)
+}
+
+func entityKey(id string) string {
+	return fmt.Sprintf("webhook:entity:%s", id)
+}
+
+// Accept atomically records the event of the entity, reporting false if it
+// was already received in the last week or it's older than the last event of
+// the entity received in the last week.
+func (g *replayGuard) Accept(ctx context.Context, eventID, entityID string, ts time.Time) (bool, error) {
+	ms := ts.UnixNano() / int64(time.Millisecond)
+	// run pre-loaded script
+	n, err := g.rdb.WithContext(ctx).EvalSha(
+		g.acceptLua,
+		[]string{eventKey(eventID), entityKey(entityID)},                                    // KEYS
+		[]string{strconv.FormatInt(ms, 10), strconv.FormatInt(eventTTL.Milliseconds(), 10)}, // ARGV
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// Forget the event, so that it's accepted if received again.
+func (g *replayGuard) Forget(ctx context.Context, eventID string) error {
+	return g.rdb.WithContext(ctx).Del(eventKey(eventID)).Err()
+}
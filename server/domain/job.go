@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"time"
+
+	"gospiga/pkg/types"
+)
+
+// JobState is the state of a job.
+type JobState string
+
+const (
+	JobRunning   JobState = "running"
+	JobDone      JobState = "done"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// ItemState is the state of a recipe loaded by a job.
+type ItemState string
+
+const (
+	ItemQueued    ItemState = "queued"
+	ItemSaved     ItemState = "saved"
+	ItemFailed    ItemState = "failed"
+	ItemSkipped   ItemState = "skipped"
+	ItemCancelled ItemState = "cancelled"
+)
+
+// Job loads the recipes in the background, tracking the state of each of
+// them.
+type Job struct {
+	ID    string
+	State JobState
+	// Total is the no. of recipes to load.
+	Total int
+	// Enqueued is the no. of recipes sent over the stream.
+	Enqueued int
+	// Queued is the no. of recipes waiting to be handled.
+	Queued     int
+	Saved      int
+	Failed     int
+	Skipped    int
+	Cancelled  int
+	Error      string
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+func (j *Job) ToType() *types.Job {
+	return &types.Job{
+		ID:         j.ID,
+		State:      string(j.State),
+		Total:      j.Total,
+		Enqueued:   j.Enqueued,
+		Pending:    j.Queued,
+		Saved:      j.Saved,
+		Failed:     j.Failed,
+		Skipped:    j.Skipped,
+		Cancelled:  j.Cancelled,
+		Error:      j.Error,
+		CreatedAt:  j.CreatedAt,
+		FinishedAt: j.FinishedAt,
+	}
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"time"
+)
+
+// OutboxOp is the change to a recipe recorded in the outbox.
+type OutboxOp string
+
+const (
+	OutboxSave   = "save"
+	OutboxDelete = "delete"
+)
+
+// OutboxEntry is a change to a recipe, stored along with the change itself,
+// waiting to be published.
+type OutboxEntry struct {
+	ID        string
+	EventID   string
+	Op        OutboxOp
+	RecipeID  string
+	Recipe    *Recipe
+	CreatedAt time.Time
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 )
 
 // DB defines the domain database capabilities.
@@ -11,5 +12,10 @@ type DB interface {
 	DeleteRecipe(context.Context, string) error
 	GetRecipeByID(context.Context, string) (*Recipe, error)
 	GetRecipesByUIDs(context.Context, []string) ([]*Recipe, error)
+	GetRecipeBySlug(context.Context, string) (*Recipe, error)
+	GetRecipesByXIDs(context.Context, []string) ([]*Recipe, error)
+	GetRecipeVersions(context.Context) (map[string]time.Time, error)
 	IDSaved(context.Context, string) (bool, error)
+	PendingOutbox(context.Context, int) ([]*OutboxEntry, error)
+	DeleteOutbox(context.Context, []string) error
 }
@@ -49,6 +49,11 @@ type Ingredient struct {
 	UnitOfMeasure string      `json:"unitOfMeasure,omitempty"`
 }
 
+type Food struct {
+	ID   string `json:"uid,omitempty"`
+	Term string `json:"term,omitempty"`
+}
+
 type Step struct {
 	Heading string `json:"heading,omitempty"`
 	Body    string `json:"body,omitempty"`
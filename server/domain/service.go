@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 )
 
 // service implements the domain service interface.
@@ -34,6 +35,26 @@ func (s *service) GetRecipesByIDs(ctx context.Context, ids []string) ([]*Recipe,
 	return s.db.GetRecipesByUIDs(ctx, ids)
 }
 
+func (s *service) GetRecipeBySlug(ctx context.Context, slug string) (*Recipe, error) {
+	return s.db.GetRecipeBySlug(ctx, slug)
+}
+
+func (s *service) GetRecipesByXIDs(ctx context.Context, xids []string) ([]*Recipe, error) {
+	return s.db.GetRecipesByXIDs(ctx, xids)
+}
+
+func (s *service) GetRecipeVersions(ctx context.Context) (map[string]time.Time, error) {
+	return s.db.GetRecipeVersions(ctx)
+}
+
 func (s *service) IDSaved(ctx context.Context, id string) (bool, error) {
 	return s.db.IDSaved(ctx, id)
 }
+
+func (s *service) PendingOutbox(ctx context.Context, n int) ([]*OutboxEntry, error) {
+	return s.db.PendingOutbox(ctx, n)
+}
+
+func (s *service) DeleteOutbox(ctx context.Context, ids []string) error {
+	return s.db.DeleteOutbox(ctx, ids)
+}
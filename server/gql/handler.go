@@ -0,0 +1,60 @@
+//go:generate go run github.com/99designs/gqlgen generate
+
+package gql
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	gqlhandler "github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gin-gonic/gin"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	errs "gospiga/pkg/errors"
+	"gospiga/pkg/log"
+)
+
+// handler serves the GraphQL API.
+type handler struct {
+	srv  *gqlhandler.Server
+	repo Repository
+}
+
+// NewHandler returns the handler of the GraphQL API served with repo.
+func NewHandler(repo Repository) (*handler, error) {
+	srv := gqlhandler.New(NewExecutableSchema(Config{Resolvers: &resolver{repo}}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.SetErrorPresenter(presentError)
+	return &handler{srv: srv, repo: repo}, nil
+}
+
+// Serve executes the query of the request.
+func (h *handler) Serve(c *gin.Context) {
+	h.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := withLoaders(r.Context(), h.repo)
+	h.srv.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// presentError labels the resolver errors with their kind, sent along with
+// the data. The internal ones are logged instead.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gerr := graphql.DefaultErrorPresenter(ctx, err)
+	if gerr.Unwrap() == nil {
+		// not from a resolver
+		return gerr
+	}
+	kind := errs.KindOf(err)
+	if kind == errs.KindInternal {
+		log.Errorf("error resolving %v: %s", gerr.Path, err)
+		gerr.Message = http.StatusText(http.StatusInternalServerError)
+	}
+	gerr.Extensions = map[string]interface{}{"code": kind}
+	return gerr
+}
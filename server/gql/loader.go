@@ -0,0 +1,164 @@
+package gql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gospiga/server/domain"
+)
+
+const (
+	// loadWait is how long a loader waits for more keys before fetching.
+	loadWait = 2 * time.Millisecond
+	// maxLoad is the max no. of keys fetched at once.
+	maxLoad = 100
+)
+
+// loader batches the keys loaded by the resolvers running concurrently into
+// a single fetch, caching the values for the rest of the request.
+type loader struct {
+	ctx   context.Context
+	fetch func(ctx context.Context, keys []string) (map[string]interface{}, error)
+
+	mu    sync.Mutex
+	batch *batch
+	cache map[string]*batch
+}
+
+// batch of keys fetched together.
+type batch struct {
+	keys   []string
+	values map[string]interface{}
+	err    error
+	done   chan struct{}
+}
+
+func newLoader(ctx context.Context, fetch func(context.Context, []string) (map[string]interface{}, error)) *loader {
+	return &loader{
+		ctx:   ctx,
+		fetch: fetch,
+		cache: make(map[string]*batch),
+	}
+}
+
+// load returns the value of the key, nil if not found.
+func (l *loader) load(key string) (interface{}, error) {
+	l.mu.Lock()
+	b, ok := l.cache[key]
+	if !ok {
+		if l.batch == nil {
+			l.batch = &batch{done: make(chan struct{})}
+			nb := l.batch
+			time.AfterFunc(loadWait, func() { l.dispatch(nb) })
+		}
+		b = l.batch
+		b.keys = append(b.keys, key)
+		l.cache[key] = b
+		if len(b.keys) == maxLoad {
+			l.batch = nil
+			go l.run(b)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return b.values[key], b.err
+	case <-l.ctx.Done():
+		return nil, l.ctx.Err()
+	}
+}
+
+// dispatch fetches the keys of the batch, unless already full and fetched.
+func (l *loader) dispatch(b *batch) {
+	l.mu.Lock()
+	if l.batch != b {
+		l.mu.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mu.Unlock()
+
+	l.run(b)
+}
+
+func (l *loader) run(b *batch) {
+	b.values, b.err = l.fetch(l.ctx, b.keys)
+	close(b.done)
+}
+
+// loaders of a request.
+type loaders struct {
+	recipes     *loader
+	tagRecipes  *loader
+	foodRecipes *loader
+}
+
+type loadersKey struct{}
+
+// withLoaders returns a copy of ctx carrying new loaders over repo.
+func withLoaders(ctx context.Context, repo Repository) context.Context {
+	ls := &loaders{
+		recipes: newLoader(ctx, func(ctx context.Context, uids []string) (map[string]interface{}, error) {
+			rs, err := repo.GetRecipesByUIDs(ctx, uids)
+			if err != nil {
+				return nil, err
+			}
+			values := make(map[string]interface{}, len(rs))
+			for _, r := range rs {
+				values[r.ID] = r
+			}
+			return values, nil
+		}),
+		tagRecipes: newLoader(ctx, func(ctx context.Context, names []string) (map[string]interface{}, error) {
+			return uidsFetch(repo.TagsRecipeUIDs(ctx, names))
+		}),
+		foodRecipes: newLoader(ctx, func(ctx context.Context, terms []string) (map[string]interface{}, error) {
+			return uidsFetch(repo.FoodsRecipeUIDs(ctx, terms))
+		}),
+	}
+	return context.WithValue(ctx, loadersKey{}, ls)
+}
+
+func uidsFetch(uids map[string][]string, err error) (map[string]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{}, len(uids))
+	for k, v := range uids {
+		values[k] = v
+	}
+	return values, nil
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// recipe returns the recipe with the given uid, nil if not found.
+func (ls *loaders) recipe(uid string) (*domain.Recipe, error) {
+	v, err := ls.recipes.load(uid)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return v.(*domain.Recipe), nil
+}
+
+// tagRecipeUIDs returns the uids of the recipes of the tag.
+func (ls *loaders) tagRecipeUIDs(name string) ([]string, error) {
+	v, err := ls.tagRecipes.load(name)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return v.([]string), nil
+}
+
+// foodRecipeUIDs returns the uids of the recipes using the food.
+func (ls *loaders) foodRecipeUIDs(term string) ([]string, error) {
+	v, err := ls.foodRecipes.load(term)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return v.([]string), nil
+}
@@ -0,0 +1,58 @@
+package gql
+
+import (
+	"gospiga/server/domain"
+)
+
+// RecipeConnection is a page of recipes.
+type RecipeConnection struct {
+	Edges    []*RecipeEdge
+	PageInfo *PageInfo
+}
+
+// newRecipeConnection returns the connection to the recipes with the given
+// uids, loaded only if their fields are asked for.
+func newRecipeConnection(uids []string, hasNext bool) *RecipeConnection {
+	rc := &RecipeConnection{
+		Edges:    make([]*RecipeEdge, 0, len(uids)),
+		PageInfo: &PageInfo{HasNextPage: hasNext},
+	}
+	for _, uid := range uids {
+		rc.Edges = append(rc.Edges, &RecipeEdge{UID: uid})
+	}
+	if len(uids) > 0 {
+		rc.PageInfo.EndCursor = endCursor(uids[len(uids)-1])
+	}
+	return rc
+}
+
+// RecipeEdge is a recipe of a connection, its node is loaded by uid.
+type RecipeEdge struct {
+	UID string
+}
+
+func (e *RecipeEdge) Cursor() string {
+	return encodeCursor(e.UID)
+}
+
+// FoodConnection is a page of foods.
+type FoodConnection struct {
+	Edges    []*FoodEdge
+	PageInfo *PageInfo
+}
+
+type FoodEdge struct {
+	Cursor string
+	Node   *domain.Food
+}
+
+// PageInfo of a connection.
+type PageInfo struct {
+	EndCursor   *string
+	HasNextPage bool
+}
+
+func endCursor(uid string) *string {
+	c := encodeCursor(uid)
+	return &c
+}
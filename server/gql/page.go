@@ -0,0 +1,74 @@
+package gql
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	errs "gospiga/pkg/errors"
+)
+
+const (
+	defaultFirst = 20
+	maxFirst     = 100
+	cursorPrefix = "uid:"
+)
+
+// parsePage returns the no. of items of the page and the uid they come
+// after, if any, out of the arguments of the paginated fields.
+func parsePage(first *int, after *string) (int, string, error) {
+	n := defaultFirst
+	if first != nil {
+		n = *first
+	}
+	if n < 0 || n > maxFirst {
+		return 0, "", errs.Validation(fmt.Errorf("first must be between 0 and %d", maxFirst))
+	}
+	if after == nil || *after == "" {
+		return n, "", nil
+	}
+	uid, err := decodeCursor(*after)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, uid, nil
+}
+
+func encodeCursor(uid string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + uid))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(b), cursorPrefix) {
+		return "", errs.Validation(errors.New("invalid cursor"))
+	}
+	return strings.TrimPrefix(string(b), cursorPrefix), nil
+}
+
+// uidValue returns the numeric value of the uid, uids are hex numbers.
+func uidValue(uid string) uint64 {
+	n, _ := strconv.ParseUint(strings.TrimPrefix(uid, "0x"), 16, 64)
+	return n
+}
+
+// page returns up to first uids coming after the given one, in uid order
+// as Dgraph pages them, reporting whether more are left.
+func page(uids []string, first int, after string) ([]string, bool) {
+	sorted := make([]string, len(uids))
+	copy(sorted, uids)
+	sort.Slice(sorted, func(i, j int) bool { return uidValue(sorted[i]) < uidValue(sorted[j]) })
+
+	if after != "" {
+		a := uidValue(after)
+		n := sort.Search(len(sorted), func(i int) bool { return uidValue(sorted[i]) > a })
+		sorted = sorted[n:]
+	}
+	if len(sorted) > first {
+		return sorted[:first], true
+	}
+	return sorted, false
+}
@@ -0,0 +1,18 @@
+package gql
+
+import (
+	"context"
+
+	"gospiga/server/domain"
+)
+
+// Repository defines the reads the GraphQL API is served with.
+type Repository interface {
+	GetRecipeByID(ctx context.Context, id string) (*domain.Recipe, error)
+	GetRecipesByUIDs(ctx context.Context, uids []string) ([]*domain.Recipe, error)
+	RecipeUIDs(ctx context.Context, first int, after string) ([]string, error)
+	GetTags(ctx context.Context) ([]*domain.Tag, error)
+	TagsRecipeUIDs(ctx context.Context, names []string) (map[string][]string, error)
+	GetFoods(ctx context.Context, first int, after string) ([]*domain.Food, error)
+	FoodsRecipeUIDs(ctx context.Context, terms []string) (map[string][]string, error)
+}
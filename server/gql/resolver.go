@@ -0,0 +1,184 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+
+	errs "gospiga/pkg/errors"
+	"gospiga/server/domain"
+)
+
+// resolver is the root resolver of the schema.
+type resolver struct {
+	repo Repository
+}
+
+func (r *resolver) Query() QueryResolver {
+	return &queryResolver{r}
+}
+
+func (r *resolver) Recipe() RecipeResolver {
+	return &recipeResolver{r}
+}
+
+func (r *resolver) Ingredient() IngredientResolver {
+	return &ingredientResolver{r}
+}
+
+func (r *resolver) Step() StepResolver {
+	return &stepResolver{r}
+}
+
+func (r *resolver) Tag() TagResolver {
+	return &tagResolver{r}
+}
+
+func (r *resolver) Food() FoodResolver {
+	return &foodResolver{r}
+}
+
+func (r *resolver) RecipeEdge() RecipeEdgeResolver {
+	return &recipeEdgeResolver{r}
+}
+
+type queryResolver struct{ *resolver }
+
+func (r *queryResolver) Recipe(ctx context.Context, id string) (*domain.Recipe, error) {
+	return r.repo.GetRecipeByID(ctx, id)
+}
+
+func (r *queryResolver) Recipes(ctx context.Context, first *int, after *string) (*RecipeConnection, error) {
+	n, uid, err := parsePage(first, after)
+	if err != nil {
+		return nil, err
+	}
+	// one more to know if there's a next page
+	uids, err := r.repo.RecipeUIDs(ctx, n+1, uid)
+	if err != nil {
+		return nil, err
+	}
+	return newRecipeConnection(page(uids, n, "")), nil
+}
+
+func (r *queryResolver) Tags(ctx context.Context) ([]*domain.Tag, error) {
+	return r.repo.GetTags(ctx)
+}
+
+func (r *queryResolver) Foods(ctx context.Context, first *int, after *string) (*FoodConnection, error) {
+	n, uid, err := parsePage(first, after)
+	if err != nil {
+		return nil, err
+	}
+	foods, err := r.repo.GetFoods(ctx, n+1, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	fc := &FoodConnection{
+		Edges:    make([]*FoodEdge, 0, len(foods)),
+		PageInfo: &PageInfo{},
+	}
+	if len(foods) > n {
+		foods = foods[:n]
+		fc.PageInfo.HasNextPage = true
+	}
+	for _, f := range foods {
+		fc.Edges = append(fc.Edges, &FoodEdge{
+			Cursor: encodeCursor(f.ID),
+			Node:   f,
+		})
+	}
+	if len(foods) > 0 {
+		fc.PageInfo.EndCursor = endCursor(foods[len(foods)-1].ID)
+	}
+	return fc, nil
+}
+
+type recipeResolver struct{ *resolver }
+
+func (r *recipeResolver) MainImage(ctx context.Context, obj *domain.Recipe) (*string, error) {
+	if obj.MainImage == nil || obj.MainImage.URL == "" {
+		return nil, nil
+	}
+	return &obj.MainImage.URL, nil
+}
+
+func (r *recipeResolver) Difficulty(ctx context.Context, obj *domain.Recipe) (string, error) {
+	return string(obj.Difficulty), nil
+}
+
+func (r *recipeResolver) Cost(ctx context.Context, obj *domain.Recipe) (string, error) {
+	return string(obj.Cost), nil
+}
+
+type ingredientResolver struct{ *resolver }
+
+func (r *ingredientResolver) Quantity(ctx context.Context, obj *domain.Ingredient) (*string, error) {
+	if obj.Quantity == nil {
+		return nil, nil
+	}
+	q := fmt.Sprint(obj.Quantity)
+	return &q, nil
+}
+
+func (r *ingredientResolver) UnitOfMeasure(ctx context.Context, obj *domain.Ingredient) (*string, error) {
+	if obj.UnitOfMeasure == "" {
+		return nil, nil
+	}
+	return &obj.UnitOfMeasure, nil
+}
+
+// Food of the ingredient, foods are stored by the ingredient names.
+func (r *ingredientResolver) Food(ctx context.Context, obj *domain.Ingredient) (*domain.Food, error) {
+	return &domain.Food{Term: obj.Name}, nil
+}
+
+type stepResolver struct{ *resolver }
+
+func (r *stepResolver) Image(ctx context.Context, obj *domain.Step) (*string, error) {
+	if obj.Image == nil || obj.Image.URL == "" {
+		return nil, nil
+	}
+	return &obj.Image.URL, nil
+}
+
+type tagResolver struct{ *resolver }
+
+func (r *tagResolver) Recipes(ctx context.Context, obj *domain.Tag, first *int, after *string) (*RecipeConnection, error) {
+	n, uid, err := parsePage(first, after)
+	if err != nil {
+		return nil, err
+	}
+	uids, err := loadersFrom(ctx).tagRecipeUIDs(obj.TagName)
+	if err != nil {
+		return nil, err
+	}
+	return newRecipeConnection(page(uids, n, uid)), nil
+}
+
+type foodResolver struct{ *resolver }
+
+func (r *foodResolver) Recipes(ctx context.Context, obj *domain.Food, first *int, after *string) (*RecipeConnection, error) {
+	n, uid, err := parsePage(first, after)
+	if err != nil {
+		return nil, err
+	}
+	uids, err := loadersFrom(ctx).foodRecipeUIDs(obj.Term)
+	if err != nil {
+		return nil, err
+	}
+	return newRecipeConnection(page(uids, n, uid)), nil
+}
+
+type recipeEdgeResolver struct{ *resolver }
+
+func (r *recipeEdgeResolver) Node(ctx context.Context, obj *RecipeEdge) (*domain.Recipe, error) {
+	dr, err := loadersFrom(ctx).recipe(obj.UID)
+	if err != nil {
+		return nil, err
+	}
+	if dr == nil {
+		return nil, errs.NotFound(fmt.Errorf("recipe %q not found", obj.UID))
+	}
+	return dr, nil
+}
@@ -0,0 +1,163 @@
+package gql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gospiga/server/domain"
+)
+
+type fakeRepo struct {
+	Repository
+	mu      sync.Mutex
+	recipes map[string]*domain.Recipe
+	tags    map[string][]string
+	fetches [][]string
+}
+
+func (r *fakeRepo) GetRecipesByUIDs(ctx context.Context, uids []string) ([]*domain.Recipe, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fetches = append(r.fetches, uids)
+	var rs []*domain.Recipe
+	for _, uid := range uids {
+		if dr, ok := r.recipes[uid]; ok {
+			rs = append(rs, dr)
+		}
+	}
+	return rs, nil
+}
+
+func (r *fakeRepo) RecipeUIDs(ctx context.Context, first int, after string) ([]string, error) {
+	var uids []string
+	for uid := range r.recipes {
+		uids = append(uids, uid)
+	}
+	uids, _ = page(uids, first, after)
+	return uids, nil
+}
+
+func (r *fakeRepo) GetTags(ctx context.Context) ([]*domain.Tag, error) {
+	var tags []*domain.Tag
+	for name := range r.tags {
+		tags = append(tags, &domain.Tag{TagName: name})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].TagName < tags[j].TagName })
+	return tags, nil
+}
+
+func (r *fakeRepo) TagsRecipeUIDs(ctx context.Context, names []string) (map[string][]string, error) {
+	uids := make(map[string][]string)
+	for _, name := range names {
+		uids[name] = r.tags[name]
+	}
+	return uids, nil
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		fetches int
+	}{
+		{
+			name: "recipes of the tags batched",
+			query: `{
+				tags {
+					name
+					recipes {
+						edges { node { id } }
+					}
+				}
+			}`,
+			want: `{"tags":[
+				{"name":"dolci","recipes":{"edges":[{"node":{"id":"r2"}},{"node":{"id":"r10"}}]}},
+				{"name":"primi","recipes":{"edges":[{"node":{"id":"r1"}},{"node":{"id":"r2"}}]}}
+			]}`,
+			fetches: 1,
+		},
+		{
+			name: "recipes paginated",
+			query: `{
+				recipes(first: 2, after: "` + encodeCursor("0x1") + `") {
+					edges { node { title } }
+					pageInfo { endCursor hasNextPage }
+				}
+			}`,
+			want: `{"recipes":{
+				"edges":[{"node":{"title":"Tiramisù"}},{"node":{"title":"Panna cotta"}}],
+				"pageInfo":{"endCursor":"` + encodeCursor("0xa") + `","hasNextPage":false}
+			}}`,
+			fetches: 1,
+		},
+		{
+			name:  "uids not asked for not fetched",
+			query: `{ recipes { edges { cursor } } }`,
+			want: `{"recipes":{"edges":[
+				{"cursor":"` + encodeCursor("0x1") + `"},
+				{"cursor":"` + encodeCursor("0x2") + `"},
+				{"cursor":"` + encodeCursor("0xa") + `"}
+			]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			repo := &fakeRepo{
+				recipes: map[string]*domain.Recipe{
+					"0x1": {ID: "0x1", ExternalID: "r1", Title: "Lasagne"},
+					"0x2": {ID: "0x2", ExternalID: "r2", Title: "Tiramisù"},
+					"0xa": {ID: "0xa", ExternalID: "r10", Title: "Panna cotta"},
+				},
+				tags: map[string][]string{
+					"primi": {"0x2", "0x1"},
+					"dolci": {"0xa", "0x2"},
+				},
+			}
+			h, err := NewHandler(repo)
+			require.NoError(err)
+
+			body, err := json.Marshal(map[string]string{"query": tt.query})
+			require.NoError(err)
+			req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			var resp struct {
+				Data   json.RawMessage
+				Errors []interface{}
+			}
+			require.NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.Empty(resp.Errors)
+			require.JSONEq(tt.want, string(resp.Data))
+			require.Len(repo.fetches, tt.fetches)
+		})
+	}
+}
+
+func TestPageCursor(t *testing.T) {
+	require := require.New(t)
+	after := "nope"
+	_, _, err := parsePage(nil, &after)
+	require.Error(err)
+
+	c := encodeCursor("0x2")
+	first := 1
+	n, uid, err := parsePage(&first, &c)
+	require.NoError(err)
+	require.Equal(1, n)
+	uids, more := page([]string{"0xa", "0x1", "0x2", "0x3"}, n, uid)
+	require.Equal([]string{"0x3"}, uids)
+	require.True(more)
+}
@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	errs "gospiga/pkg/errors"
+	"gospiga/pkg/events"
+	"gospiga/pkg/log"
+	"gospiga/pkg/streamer"
+	"gospiga/pkg/types"
+	"gospiga/server/domain"
+)
+
+// LoadRecipes in the platform by injecting all the recipe IDs retrieved from
+// the provider over the bulk stream, in the background. It returns the job
+// tracking the load, only one job runs at a time. A load stopped halfway is
+// resumed by the next one, skipping the recipe IDs sent already.
+func (a *app) LoadRecipes(ctx context.Context) (*types.Job, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, err
+	}
+	id := hex.EncodeToString(b)
+
+	err = a.jobs.CreateJob(ctx, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	jctx, cancel := context.WithCancel(a.ctx)
+	a.mu.Lock()
+	a.cancels[id] = cancel
+	a.mu.Unlock()
+
+	go func() {
+		defer func() {
+			a.mu.Lock()
+			delete(a.cancels, id)
+			a.mu.Unlock()
+			cancel()
+		}()
+
+		err := a.loadRecipes(jctx, id)
+		if err == nil {
+			return
+		}
+		log.Errorf("error loading recipes of job %q: %s", id, err)
+		// the job context may be done already
+		_, err = a.jobs.FinishJob(context.Background(), id, domain.JobFailed, err.Error())
+		if err != nil {
+			log.Errorf("error finishing job %q: %s", id, err)
+		}
+	}()
+
+	return a.GetJob(ctx, id)
+}
+
+// loadRecipes sends the recipe IDs of the job over the bulk stream.
+func (a *app) loadRecipes(ctx context.Context, jobID string) error {
+	rids, err := a.provider.GetAllRecipeIDs(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Strings(rids)
+	rids = unique(rids)
+
+	err = a.jobs.AddItems(ctx, jobID, rids)
+	if err != nil {
+		return err
+	}
+
+	msgs := make([]*streamer.Message, 0, len(rids))
+	for _, id := range rids {
+		e := events.NewRecipeCreated(source, id)
+		e.JobID = jobID
+		msg, err := events.Encode(e, encoding)
+		if err != nil {
+			return err
+		}
+		msg.Key = id
+		msgs = append(msgs, msg)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	res, err := a.streamer.AddMany(bulkRecipeStream, loadBatch, msgs)
+	log.Infof("loaded %d recipe ID(s) out of %d", res.Added, len(rids))
+	var failed streamer.BulkError
+	if errors.As(err, &failed) {
+		ids := make([]string, 0, len(failed))
+		for i, ferr := range failed {
+			log.Errorf("error loading recipe ID %q: %s", rids[i], ferr)
+			ids = append(ids, rids[i])
+		}
+		_, err = a.jobs.SetItems(ctx, jobID, domain.ItemFailed, ids...)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the ones sent by a previous load stopped halfway
+	if len(res.Skipped) > 0 {
+		ids := make([]string, 0, len(res.Skipped))
+		for _, i := range res.Skipped {
+			ids = append(ids, rids[i])
+		}
+		_, err = a.jobs.SetItems(ctx, jobID, domain.ItemSkipped, ids...)
+		if err != nil {
+			return err
+		}
+	}
+	return a.jobs.SetLoaded(ctx, jobID, res.Added)
+}
+
+// loadRecipe saves a recipe sent by LoadRecipes, recording it as saved in its
+// job. The recipes of a cancelled job are skipped, the ones failing are
+// recorded once dead-lettered.
+func (a *app) loadRecipe(ctx context.Context, msg streamer.Message, e *events.RecipeCreated) error {
+	if a.jobs == nil || e.JobID == "" {
+		return a.saveRecipe(ctx, msg, e)
+	}
+
+	job, err := a.jobs.GetJob(ctx, e.JobID)
+	if err != nil {
+		return err
+	}
+	if job != nil && job.State == domain.JobCancelled {
+		n, err := a.jobs.SetItems(ctx, e.JobID, domain.ItemCancelled, e.RecipeID)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			log.Debugf("skipping recipe ID %q of cancelled job %q", e.RecipeID, e.JobID)
+			return nil
+		}
+	}
+
+	err = a.saveRecipe(ctx, msg, e)
+	if err != nil {
+		return err
+	}
+	_, err = a.jobs.SetItems(ctx, e.JobID, domain.ItemSaved, e.RecipeID)
+	if err != nil {
+		log.Errorf("error recording recipe ID %q in job %q: %s", e.RecipeID, e.JobID, err)
+	}
+	return nil
+}
+
+// deadLettered records the recipes of the jobs moved to the dead-letter
+// stream as failed, whether their handler ran or not.
+func (a *app) deadLettered(msg streamer.Message, reason string) {
+	if a.jobs == nil || msg.Stream != bulkRecipeStream {
+		return
+	}
+	e, err := events.DecodeRecipeCreated(msg)
+	if err != nil {
+		log.Errorf("can't tell the job of dead-lettered msg ID %q: %s", msg.ID, err)
+		return
+	}
+	if e.JobID == "" {
+		return
+	}
+	_, err = a.jobs.SetItems(a.ctx, e.JobID, domain.ItemFailed, e.RecipeID)
+	if err != nil {
+		log.Errorf("error recording recipe ID %q in job %q: %s", e.RecipeID, e.JobID, err)
+	}
+}
+
+// GetJob returns the job with the given ID.
+func (a *app) GetJob(ctx context.Context, jobID string) (*types.Job, error) {
+	job, err := a.jobs.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, errs.NotFound(fmt.Errorf("job %q not found", jobID))
+	}
+	return job.ToType(), nil
+}
+
+// CancelJob stops the job if running. The recipes already sent over the
+// stream are skipped when handled.
+func (a *app) CancelJob(ctx context.Context, jobID string) (*types.Job, error) {
+	ok, err := a.jobs.FinishJob(ctx, jobID, domain.JobCancelled, "cancelled")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		job, err := a.GetJob(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errs.Conflict(fmt.Errorf("job %q is %s", jobID, job.State))
+	}
+
+	// the job may be running on another replica
+	a.mu.Lock()
+	if cancel, ok := a.cancels[jobID]; ok {
+		cancel()
+	}
+	a.mu.Unlock()
+
+	return a.GetJob(ctx, jobID)
+}
+
+// unique returns the sorted strings without duplicates.
+func unique(ss []string) []string {
+	out := ss[:0]
+	for _, s := range ss {
+		if len(out) > 0 && s == out[len(out)-1] {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
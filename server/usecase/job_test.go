@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gospiga/pkg/streamer"
+	"gospiga/pkg/types"
+	"gospiga/server/domain"
+)
+
+func (p *fakeProvider) GetAllRecipeIDs(ctx context.Context) ([]string, error) {
+	if p.block != nil {
+		select {
+		case <-p.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return p.ids, p.err
+}
+
+type fakeJobs struct {
+	mu     sync.Mutex
+	jobs   map[string]*domain.Job
+	items  map[string]map[string]domain.ItemState
+	loaded map[string]bool
+}
+
+func newFakeJobs() *fakeJobs {
+	return &fakeJobs{
+		jobs:   make(map[string]*domain.Job),
+		items:  make(map[string]map[string]domain.ItemState),
+		loaded: make(map[string]bool),
+	}
+}
+
+func (s *fakeJobs) CreateJob(ctx context.Context, id string, createdAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[id] = &domain.Job{ID: id, State: domain.JobRunning, CreatedAt: createdAt}
+	s.items[id] = make(map[string]domain.ItemState)
+	return nil
+}
+
+func (s *fakeJobs) AddItems(ctx context.Context, id string, items []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range items {
+		s.items[id][item] = domain.ItemQueued
+	}
+	s.jobs[id].Total, s.jobs[id].Queued = len(items), len(items)
+	return nil
+}
+
+func (s *fakeJobs) SetItems(ctx context.Context, id string, state domain.ItemState, items ...string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[id]
+	n := 0
+	for _, item := range items {
+		if s.items[id][item] != domain.ItemQueued {
+			continue
+		}
+		s.items[id][item] = state
+		job.Queued--
+		switch state {
+		case domain.ItemSaved:
+			job.Saved++
+		case domain.ItemFailed:
+			job.Failed++
+		case domain.ItemSkipped:
+			job.Skipped++
+		case domain.ItemCancelled:
+			job.Cancelled++
+		}
+		n++
+	}
+	if job.State == domain.JobRunning && s.loaded[id] && job.Queued == 0 {
+		job.State = domain.JobDone
+	}
+	return n, nil
+}
+
+func (s *fakeJobs) SetLoaded(ctx context.Context, id string, enqueued int) error {
+	s.mu.Lock()
+	s.jobs[id].Enqueued = enqueued
+	s.loaded[id] = true
+	s.mu.Unlock()
+	_, err := s.SetItems(ctx, id, domain.ItemQueued)
+	return err
+}
+
+func (s *fakeJobs) FinishJob(ctx context.Context, id string, state domain.JobState, reason string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok || job.State != domain.JobRunning {
+		return false, nil
+	}
+	job.State, job.Error = state, reason
+	return true, nil
+}
+
+func (s *fakeJobs) GetJob(ctx context.Context, id string) (*domain.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	j := *job
+	return &j, nil
+}
+
+func TestLoadRecipes(t *testing.T) {
+	tests := []struct {
+		name   string
+		block  bool
+		cancel bool
+		want   types.Job
+	}{
+		{
+			name: "job tracks the recipes",
+			want: types.Job{State: string(domain.JobDone), Total: 2, Enqueued: 2, Saved: 1, Failed: 1},
+		},
+		{
+			name:   "job cancelled while loading",
+			block:  true,
+			cancel: true,
+			want:   types.Job{State: string(domain.JobCancelled), Error: "cancelled"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			ctx := context.Background()
+			s := streamer.NewMemoryStreamer()
+			svc := &fakeService{saved: make(map[string]*domain.Recipe)}
+			// r2 is missing, failing permanently
+			p := &fakeProvider{
+				recipes: map[string]*types.Recipe{
+					"r1": {ExternalID: "r1", Title: "title", MainImage: &types.Image{}},
+				},
+				ids: []string{"r2", "r1", "r1"},
+			}
+			if tt.block {
+				p.block = make(chan struct{})
+				defer close(p.block)
+			}
+
+			prod, err := streamer.NewProducer(ctx, s, &streamer.ProducerArgs{})
+			require.NoError(err)
+			a := NewApp(svc, nil, s, prod, newFakeJobs(), p, nil, 1)
+			defer a.CloseGracefully()
+
+			job, err := a.LoadRecipes(ctx)
+			require.NoError(err)
+			require.Equal(string(domain.JobRunning), job.State)
+			if tt.cancel {
+				_, err := a.CancelJob(ctx, job.ID)
+				require.NoError(err)
+			}
+
+			require.Eventually(func() bool {
+				job, err = a.GetJob(ctx, job.ID)
+				return err == nil && job.State != string(domain.JobRunning)
+			}, 5*time.Second, 10*time.Millisecond)
+			got := *job
+			got.ID, got.CreatedAt = "", time.Time{}
+			require.Equal(tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"gospiga/pkg/events"
+	"gospiga/pkg/log"
+	"gospiga/server/domain"
+)
+
+const (
+	outboxBatch    = 100
+	outboxInterval = time.Second
+)
+
+// relayOutbox publishes the pending outbox entries every interval until ctx
+// is done. Entries are deleted once published, an entry published and not
+// deleted is published again with the same event ID.
+func (a *app) relayOutbox(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := a.relayPending(ctx)
+		if err != nil {
+			log.Errorf("error relaying outbox: %s", err)
+		}
+
+		// keep going while there's a backlog
+		if err == nil && n == outboxBatch {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// relayPending publishes a batch of pending outbox entries, in the order they
+// were written, returning the no. of entries delivered.
+func (a *app) relayPending(ctx context.Context) (int, error) {
+	entries, err := a.service.PendingOutbox(ctx, outboxBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := make([]string, 0, len(entries))
+	for _, o := range entries {
+		err = a.relayEntry(ctx, o)
+		if err != nil {
+			// stop here to keep the order of the changes
+			break
+		}
+		delivered = append(delivered, o.ID)
+	}
+
+	merr := a.service.DeleteOutbox(ctx, delivered)
+	if merr != nil {
+		return 0, merr
+	}
+	return len(delivered), err
+}
+
+// relayEntry publishes the event of the outbox entry over its stream. Events
+// are never spooled, the entry is kept until they are sent.
+func (a *app) relayEntry(ctx context.Context, o *domain.OutboxEntry) error {
+	meta := events.Meta{
+		Version:   events.SchemaVersion,
+		ID:        o.EventID,
+		Timestamp: o.CreatedAt.UTC(),
+		Source:    source,
+	}
+
+	switch o.Op {
+	case domain.OutboxSave:
+		if o.Recipe == nil {
+			log.Infof("recipe ID %q deleted before being relayed", o.RecipeID)
+			return nil
+		}
+		return a.relay(ctx, savedRecipeStream, &events.RecipeSaved{Meta: meta, Recipe: o.Recipe.ToType()})
+	case domain.OutboxDelete:
+		return a.relay(ctx, removedRecipeStream, &events.RecipeDeleted{Meta: meta, RecipeID: o.RecipeID})
+	}
+
+	log.Warnf("unknown outbox operation %q for recipe ID %q", o.Op, o.RecipeID)
+	return nil
+}
+
+// relay sends the event over the given stream.
+func (a *app) relay(ctx context.Context, stream string, e events.Event) error {
+	msg, err := events.Encode(e, encoding)
+	if err != nil {
+		return err
+	}
+	return a.producer.Send(ctx, stream, msg)
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"gospiga/pkg/streamer"
 	"gospiga/pkg/types"
@@ -18,14 +19,39 @@ type Service interface {
 	DeleteRecipe(context.Context, string) error
 	GetRecipeByID(context.Context, string) (*domain.Recipe, error)
 	GetRecipesByIDs(context.Context, []string) ([]*domain.Recipe, error)
+	GetRecipeBySlug(context.Context, string) (*domain.Recipe, error)
+	GetRecipesByXIDs(context.Context, []string) ([]*domain.Recipe, error)
+	GetRecipeVersions(context.Context) (map[string]time.Time, error)
 	IDSaved(context.Context, string) (bool, error)
+	PendingOutbox(context.Context, int) ([]*domain.OutboxEntry, error)
+	DeleteOutbox(context.Context, []string) error
 }
 
 type Streamer interface {
 	Ack(stream, group string, ids ...string) error
-	Add(string, *streamer.Message) error
-	AckAndAdd(fromStream, toStream, group, id string, msg *streamer.Message) error
-	ReadGroup(*streamer.StreamArgs) error
+	AddContext(ctx context.Context, stream string, msg *streamer.Message) error
+	AddMany(stream, batch string, msgs []*streamer.Message) (*streamer.BulkResult, error)
+	ReadGroup(context.Context, *streamer.StreamArgs) error
+	Trim(stream string, r *streamer.Retention) (int64, error)
+	Info(streams ...string) ([]*streamer.StreamInfo, error)
+	SetGroupID(stream, group, id string) error
+	DeadLetters(stream string, count int64) ([]*streamer.DeadLetter, error)
+	GetDeadLetter(stream, id string) (*streamer.DeadLetter, error)
+	Redrive(stream, id string) (string, error)
+}
+
+type Producer interface {
+	Add(ctx context.Context, stream string, msg *streamer.Message) error
+	Send(ctx context.Context, stream string, msg *streamer.Message) error
+}
+
+type JobStore interface {
+	CreateJob(ctx context.Context, id string, createdAt time.Time) error
+	AddItems(ctx context.Context, id string, items []string) error
+	SetItems(ctx context.Context, id string, state domain.ItemState, items ...string) (int, error)
+	SetLoaded(ctx context.Context, id string, enqueued int) error
+	FinishJob(ctx context.Context, id string, state domain.JobState, reason string) (bool, error)
+	GetJob(ctx context.Context, id string) (*domain.Job, error)
 }
 
 type Provider interface {
@@ -33,6 +59,11 @@ type Provider interface {
 	GetAllRecipeIDs(context.Context) ([]string, error)
 }
 
+// VersionProvider is a provider telling when its recipes were last updated.
+type VersionProvider interface {
+	GetRecipeVersions(context.Context) (map[string]time.Time, error)
+}
+
 type Stub interface {
 	AllRecipeTags(context.Context) ([]string, error)
 }
@@ -3,34 +3,119 @@ package usecase
 import (
 	"context"
 	"errors"
-	"sync"
+	"fmt"
+	"time"
 
 	errs "gospiga/pkg/errors"
+	"gospiga/pkg/events"
 	"gospiga/pkg/log"
 	"gospiga/pkg/streamer"
+	"gospiga/pkg/types"
 	"gospiga/server/domain"
 )
 
 const (
-	newRecipeStream     = "new-recipes"
-	updatedRecipeStream = "updated-recipes"
-	deletedRecipeStream = "deleted-recipes"
+	newRecipeStream     = events.NewRecipes
+	updatedRecipeStream = events.UpdatedRecipes
+	deletedRecipeStream = events.DeletedRecipes
+	savedRecipeStream   = events.SavedRecipes
+	removedRecipeStream = events.RemovedRecipes
+	bulkRecipeStream    = events.BulkRecipes
 	group               = "server-usecase"
+	source              = "server"
+	encoding            = events.JSON
+	maxAttempts         = 5
+	claimIdle           = 5 * time.Minute
+	heartbeat           = 10 * time.Second
+	inboxTTL            = time.Hour
+	trimInterval        = time.Hour
+	loadBatch           = "load-recipes"
+	maxBatch            = 100
 )
 
+// providerRetry is the retry policy of the streams whose handlers call the
+// provider.
+var providerRetry = &streamer.RetryPolicy{
+	MaxAttempts: maxAttempts,
+	BaseDelay:   2 * time.Second,
+	MaxDelay:    time.Minute,
+	Jitter:      0.2,
+}
+
+// streamRetention is the retention of the recipe streams. Entries are kept
+// until every group has acknowledged them.
+var streamRetention = &streamer.Retention{
+	MaxLen:    10000,
+	MaxAge:    7 * 24 * time.Hour,
+	AckedOnly: true,
+}
+
+// retention of the streams trimmed. The saved and removed recipe streams are
+// never trimmed: the search index is rebuilt by replaying them in full.
+var retention = map[string]*streamer.Retention{
+	newRecipeStream:     streamRetention,
+	updatedRecipeStream: streamRetention,
+	deletedRecipeStream: streamRetention,
+	bulkRecipeStream:    streamRetention,
+}
+
+var retryPolicies = map[string]*streamer.RetryPolicy{
+	newRecipeStream:     providerRetry,
+	updatedRecipeStream: providerRetry,
+	bulkRecipeStream:    providerRetry,
+	deletedRecipeStream: {
+		MaxAttempts: maxAttempts,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+	},
+}
+
+// weights of the recipe streams: the interactive lane, fed by the webhooks,
+// gets ahead of the bulk lane, fed by LoadRecipes.
+var weights = map[string]int{
+	newRecipeStream:     10,
+	updatedRecipeStream: 10,
+	deletedRecipeStream: 10,
+	bulkRecipeStream:    1,
+}
+
 // NewRecipe informs of a new recipe ID sending it over the stream.
 func (a *app) NewRecipe(ctx context.Context, recipeID string) error {
-	return a.streamer.Add(newRecipeStream, &streamer.Message{Payload: recipeID})
+	return a.publish(ctx, newRecipeStream, events.NewRecipeCreated(source, recipeID))
 }
 
 // UpdatedRecipe informs of an updated recipe ID sending it over the stream.
 func (a *app) UpdatedRecipe(ctx context.Context, recipeID string) error {
-	return a.streamer.Add(updatedRecipeStream, &streamer.Message{Payload: recipeID})
+	return a.publish(ctx, updatedRecipeStream, events.NewRecipeUpdated(source, recipeID))
 }
 
 // DeletedRecipe informs of an deleted recipe ID sending it over the stream.
 func (a *app) DeletedRecipe(ctx context.Context, recipeID string) error {
-	return a.streamer.Add(deletedRecipeStream, &streamer.Message{Payload: recipeID})
+	return a.publish(ctx, deletedRecipeStream, events.NewRecipeDeleted(source, recipeID))
+}
+
+// PublishedRecipe informs of a published recipe ID sending it over the
+// stream: as new if not saved yet, as updated otherwise.
+func (a *app) PublishedRecipe(ctx context.Context, recipeID string) error {
+	saved, err := a.service.IDSaved(ctx, recipeID)
+	if err != nil {
+		return err
+	}
+	if saved {
+		return a.UpdatedRecipe(ctx, recipeID)
+	}
+	return a.NewRecipe(ctx, recipeID)
+}
+
+// publish sends the event over the given stream, spooling it if the
+// streamer is unavailable.
+func (a *app) publish(ctx context.Context, stream string, e events.Event) error {
+	msg, err := events.Encode(e, encoding)
+	if err != nil {
+		return err
+	}
+	return a.producer.Add(ctx, stream, msg)
 }
 
 // RecipeTags returns the set of used tags.
@@ -43,191 +128,128 @@ func (a *app) RecipeTags(ctx context.Context) ([]string, error) {
 	return tags, nil
 }
 
-// LoadRecipes in the platform by injecting all the recipe IDs retrieved from
-// the provider.
-func (a *app) LoadRecipes(ctx context.Context) error {
-	rids, err := a.provider.GetAllRecipeIDs(ctx)
+// GetRecipe returns the recipe with the given external ID.
+func (a *app) GetRecipe(ctx context.Context, recipeID string) (*types.Recipe, error) {
+	r, err := a.service.GetRecipeByID(ctx, recipeID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	for _, id := range rids {
-		err := a.streamer.Add(newRecipeStream, &streamer.Message{Payload: id})
-		if err != nil {
-			return err
-		}
+	if r == nil {
+		return nil, errs.NotFound(fmt.Errorf("recipe ID %q not found", recipeID))
 	}
-
-	return nil
+	return r.ToType(), nil
 }
 
-func (a *app) readRecipes() {
-	ctx, exit := context.WithCancel(context.Background())
-	msgChan := make(chan streamer.Message)
-	var wg sync.WaitGroup
-
-	streams := []string{
-		newRecipeStream,
-		updatedRecipeStream,
-		deletedRecipeStream,
+// GetRecipeBySlug returns the recipe with the given slug.
+func (a *app) GetRecipeBySlug(ctx context.Context, slug string) (*types.Recipe, error) {
+	r, err := a.service.GetRecipeBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
 	}
-	args := &streamer.StreamArgs{
-		Streams:  streams,
-		Group:    group,
-		Consumer: "usecase",
-		Messages: msgChan,
-		Exit:     a.shutdown,
-		WG:       &wg,
+	if r == nil {
+		return nil, errs.NotFound(fmt.Errorf("recipe %q not found", slug))
 	}
+	return r.ToType(), nil
+}
 
-	err := a.streamer.ReadGroup(args)
+// GetRecipes returns the recipes with the given external IDs, in the same
+// order, along with the IDs not found.
+func (a *app) GetRecipes(ctx context.Context, recipeIDs []string) ([]*types.Recipe, []string, error) {
+	if len(recipeIDs) > maxBatch {
+		return nil, nil, errs.Validation(fmt.Errorf("too many recipe IDs, max %d", maxBatch))
+	}
+	rs, err := a.service.GetRecipesByXIDs(ctx, recipeIDs)
 	if err != nil {
-		log.Fatal(err)
-	}
-
-	for {
-		select {
-		case msg := <-msgChan:
-			switch msg.Stream {
-			case newRecipeStream:
-				recipeID, ok := msg.Payload.(string)
-				if !ok {
-					log.Errorf("cannot read recipe ID from message ID %q", msg.ID)
-					a.discardMessage(&msg, &wg)
-					continue
-				}
-				log.Debugf("Got message for a new recipe ID %q", recipeID)
-
-				a.saveRecipe(ctx, recipeID, msg.Stream, msg.ID, &wg)
-
-			case updatedRecipeStream:
-				recipeID, ok := msg.Payload.(string)
-				if !ok {
-					log.Errorf("cannot read recipe ID from message ID %q", msg.ID)
-					a.discardMessage(&msg, &wg)
-					continue
-				}
-				log.Debugf("Got message for updated recipe ID %q", recipeID)
-
-				a.updateRecipe(ctx, recipeID, msg.Stream, msg.ID, &wg)
-
-			case deletedRecipeStream:
-				recipeID, ok := msg.Payload.(string)
-				if !ok {
-					log.Errorf("cannot read recipe ID from message ID %q", msg.ID)
-					a.discardMessage(&msg, &wg)
-					continue
-				}
-				log.Debugf("Got message for deleted recipe ID %q", recipeID)
-
-				a.deleteRecipe(ctx, recipeID, msg.ID, &wg)
-			}
-
-		case <-a.shutdown:
-			// time to exit
-			exit()
-			return
+		return nil, nil, err
+	}
+
+	byID := make(map[string]*domain.Recipe, len(rs))
+	for _, r := range rs {
+		byID[r.ExternalID] = r
+	}
+	recipes := make([]*types.Recipe, 0, len(rs))
+	missing := []string{}
+	for _, id := range recipeIDs {
+		r, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
 		}
+		recipes = append(recipes, r.ToType())
 	}
+	return recipes, missing, nil
+}
+
+func (a *app) readRecipes(ctx context.Context, concurrency int) error {
+	a.consumer = &streamer.StreamArgs{
+		Group:    group,
+		Consumer: streamer.ConsumerName(group),
+		// replicas share the work
+		Mode: streamer.WorkSharing,
+		Handlers: map[string]streamer.Handler{
+			newRecipeStream:     events.OnRecipeCreated(a.saveRecipe),
+			updatedRecipeStream: events.OnRecipeUpdated(a.updateRecipe),
+			deletedRecipeStream: events.OnRecipeDeleted(a.deleteRecipe),
+			bulkRecipeStream:    events.OnRecipeCreated(a.loadRecipe),
+		},
+		Weights:     weights,
+		Retries:     retryPolicies,
+		MaxAttempts: maxAttempts,
+		ClaimIdle:   claimIdle,
+		Heartbeat:   heartbeat,
+		Concurrency: concurrency,
+		// keep the events of the same recipe in order
+		Key: events.Key,
+		// skip the events already handled
+		Inbox: &streamer.Inbox{TTL: inboxTTL, EventID: events.ID},
+		// the jobs loading the recipes can't tell otherwise
+		DeadLettered: a.deadLettered,
+	}
+
+	return a.streamer.ReadGroup(ctx, a.consumer)
 }
 
-func (a *app) saveRecipe(ctx context.Context, recipeID, fromStream, messageID string, wg *sync.WaitGroup) {
-	// unleash the streamer
-	defer wg.Done()
+func (a *app) saveRecipe(ctx context.Context, msg streamer.Message, e *events.RecipeCreated) error {
+	recipeID := e.RecipeID
+	log.Debugf("Got message for a new recipe ID %q", recipeID)
 
 	// call provider to get the full recipe
 	rt, err := a.provider.GetRecipe(ctx, recipeID)
 	if err != nil {
-		log.Error(err)
-		// TODO: ack?? new stream??
-		return
+		return err
 	}
 	r := domain.FromType(rt)
 
-	// save recipe
+	// save recipe, the outbox relays it
 	err = a.service.SaveRecipe(ctx, r)
 	var errdup errs.ErrDuplicateID
 	if errors.As(err, &errdup) {
 		log.Infof("recipe ID %q already saved", r.ExternalID)
-		err = a.streamer.Ack(fromStream, group, messageID)
-		if err != nil {
-			log.Errorf("error on Ack for msg ID %q", messageID)
-		}
-		return
-	}
-	if err != nil {
-		log.Error(err)
-		// TODO: ack ??
-		return
-	}
-
-	// ack message and relay
-	rMsg := &streamer.Message{
-		Payload: r.ToType(),
-	}
-	err = a.streamer.AckAndAdd(fromStream, "saved-recipes", group, messageID, rMsg)
-	if err != nil {
-		log.Errorf("error on AckAndAdd for msg ID %q", messageID)
+		return nil
 	}
+	return err
 }
 
-func (a *app) updateRecipe(ctx context.Context, recipeID, fromStream, messageID string, wg *sync.WaitGroup) {
-	// unleash the streamer
-	defer wg.Done()
+func (a *app) updateRecipe(ctx context.Context, msg streamer.Message, e *events.RecipeUpdated) error {
+	recipeID := e.RecipeID
+	log.Debugf("Got message for updated recipe ID %q", recipeID)
 
 	// call provider to get the full recipe
 	rt, err := a.provider.GetRecipe(ctx, recipeID)
-	r := domain.FromType(rt)
-	if err != nil {
-		log.Error(err)
-		// TODO: ack?? new stream??
-		return
-	}
-
-	// save recipe
-	rID, err := a.service.UpdateRecipe(ctx, r)
 	if err != nil {
-		log.Error(err)
-		// TODO: ack ??
-		return
-	}
-	if rID != "" {
-		r.ID = rID
+		return err
 	}
+	r := domain.FromType(rt)
 
-	// ack message and relay
-	rMsg := &streamer.Message{
-		Payload: r.ToType(),
-	}
-	err = a.streamer.AckAndAdd(fromStream, "saved-recipes", group, messageID, rMsg)
-	if err != nil {
-		log.Errorf("error on AckAndAdd for msg ID %q", messageID)
-	}
+	// save recipe, the outbox relays it
+	_, err = a.service.UpdateRecipe(ctx, r)
+	return err
 }
 
-func (a *app) deleteRecipe(ctx context.Context, recipeID, messageID string, wg *sync.WaitGroup) {
-	// unleash the streamer
-	defer wg.Done()
-
-	// delete recipe
-	err := a.service.DeleteRecipe(ctx, recipeID)
-	if err != nil {
-		log.Error(err)
-		// TODO: ack ??
-		return
-	}
-
-	// TODO: relay on deleted-stream??
-	err = a.streamer.Ack(deletedRecipeStream, group, messageID)
-	if err != nil {
-		log.Errorf("error on Ack for msg ID %q", messageID)
-	}
-}
+func (a *app) deleteRecipe(ctx context.Context, msg streamer.Message, e *events.RecipeDeleted) error {
+	recipeID := e.RecipeID
+	log.Debugf("Got message for deleted recipe ID %q", recipeID)
 
-func (a *app) discardMessage(m *streamer.Message, wg *sync.WaitGroup) {
-	defer wg.Done()
-	err := a.streamer.Ack(m.Stream, group, m.ID)
-	if err != nil {
-		log.Warnf("error acknowledging message: %s", err)
-	}
+	// delete recipe, the outbox relays it
+	return a.service.DeleteRecipe(ctx, recipeID)
 }
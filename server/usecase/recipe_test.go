@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gospiga/pkg/errors"
+	"gospiga/pkg/events"
+	"gospiga/pkg/streamer"
+	"gospiga/pkg/types"
+	"gospiga/server/domain"
+)
+
+type fakeService struct {
+	Service
+	mu     sync.Mutex
+	saved  map[string]*domain.Recipe
+	outbox []*domain.OutboxEntry
+	// versions are the recipes stored, if set
+	versions map[string]time.Time
+}
+
+func (s *fakeService) SaveRecipe(ctx context.Context, r *domain.Recipe) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.saved[r.ExternalID]; ok {
+		return errors.ErrDuplicateID{ID: r.ExternalID}
+	}
+	r.ID = "0x" + r.ExternalID
+	s.saved[r.ExternalID] = r
+	s.outbox = append(s.outbox, &domain.OutboxEntry{
+		ID:        fmt.Sprintf("0x%d", len(s.outbox)),
+		EventID:   "e-" + r.ExternalID,
+		Op:        domain.OutboxSave,
+		RecipeID:  r.ExternalID,
+		Recipe:    r,
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+func (s *fakeService) PendingOutbox(ctx context.Context, n int) ([]*domain.OutboxEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.outbox) < n {
+		n = len(s.outbox)
+	}
+	return s.outbox[:n], nil
+}
+
+func (s *fakeService) DeleteOutbox(ctx context.Context, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outbox = s.outbox[len(ids):]
+	return nil
+}
+
+func (s *fakeService) GetRecipesByXIDs(ctx context.Context, ids []string) ([]*domain.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rs []*domain.Recipe
+	for _, r := range s.saved {
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+type fakeProvider struct {
+	Provider
+	recipes map[string]*types.Recipe
+	ids     []string
+	err     error
+	// block holds GetAllRecipeIDs until closed, if set
+	block chan struct{}
+}
+
+func (p *fakeProvider) GetRecipe(ctx context.Context, recipeID string) (*types.Recipe, error) {
+	r, ok := p.recipes[recipeID]
+	if !ok {
+		return nil, errors.Permanent(fmt.Errorf("recipe ID %s not found", recipeID))
+	}
+	return r, nil
+}
+
+func TestNewRecipe(t *testing.T) {
+	tests := []struct {
+		name     string
+		recipeID string
+		assert   func(t *testing.T, s Streamer)
+	}{
+		{
+			name:     "recipe saved and relayed",
+			recipeID: "r1",
+			assert: func(t *testing.T, s Streamer) {
+				require := require.New(t)
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				saved := make(chan streamer.Message, 1)
+				args := &streamer.StreamArgs{
+					Group:    "test",
+					Consumer: "test",
+					Handlers: map[string]streamer.Handler{
+						savedRecipeStream: func(ctx context.Context, msg streamer.Message) error {
+							saved <- msg
+							return nil
+						},
+					},
+				}
+				require.NoError(s.ReadGroup(ctx, args))
+
+				select {
+				case msg := <-saved:
+					e, err := events.DecodeRecipeSaved(msg)
+					require.NoError(err)
+					require.Equal("server", e.Source)
+					require.Equal("e-r1", e.ID)
+					require.Equal("r1", e.Recipe.ExternalID)
+					require.Equal("0xr1", e.Recipe.ID)
+				case <-time.After(5 * time.Second):
+					t.Fatal("timeout waiting for saved recipe")
+				}
+			},
+		},
+		{
+			name:     "missing recipe dead-lettered",
+			recipeID: "r2",
+			assert: func(t *testing.T, s Streamer) {
+				require.Eventually(t, func() bool {
+					dls, err := s.DeadLetters(newRecipeStream, 10)
+					return err == nil && len(dls) == 1
+				}, 5*time.Second, 10*time.Millisecond)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := streamer.NewMemoryStreamer()
+			svc := &fakeService{saved: make(map[string]*domain.Recipe)}
+			p := &fakeProvider{recipes: map[string]*types.Recipe{
+				"r1": {ExternalID: "r1", Title: "title", MainImage: &types.Image{}},
+			}}
+
+			prod, err := streamer.NewProducer(context.Background(), s, &streamer.ProducerArgs{})
+			require.NoError(t, err)
+
+			a := NewApp(svc, nil, s, prod, nil, p, nil, 1)
+			defer a.CloseGracefully()
+
+			require.NoError(t, a.NewRecipe(context.Background(), tt.recipeID))
+			tt.assert(t, s)
+		})
+	}
+}
+
+func TestGetRecipes(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []string
+		want    []string
+		missing []string
+		err     errors.Kind
+	}{
+		{
+			name:    "recipes in the order asked",
+			ids:     []string{"r2", "r3", "r1"},
+			want:    []string{"r2", "r1"},
+			missing: []string{"r3"},
+		},
+		{
+			name: "too many recipes",
+			ids:  make([]string, maxBatch+1),
+			err:  errors.KindValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			svc := &fakeService{saved: map[string]*domain.Recipe{
+				"r1": {ExternalID: "r1"},
+				"r2": {ExternalID: "r2"},
+			}}
+			a := &app{service: svc}
+
+			recipes, missing, err := a.GetRecipes(context.Background(), tt.ids)
+			if tt.err != "" {
+				require.Equal(tt.err, errors.KindOf(err))
+				return
+			}
+			require.NoError(err)
+			var got []string
+			for _, r := range recipes {
+				got = append(got, r.ExternalID)
+			}
+			require.Equal(tt.want, got)
+			require.Equal(tt.missing, missing)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	errs "gospiga/pkg/errors"
+	"gospiga/pkg/log"
+	"gospiga/pkg/types"
+)
+
+// Reconcile compares the recipes on the provider with the ones stored,
+// sending the events of the changes missed: new recipes for the ones
+// missing, deleted recipes for the ones gone from the provider and updated
+// recipes for the ones stored before their last update, if the provider
+// tells. A dry run only reports the changes.
+func (a *app) Reconcile(ctx context.Context, dryRun bool) (*types.Reconciliation, error) {
+	rids, err := a.provider.GetAllRecipeIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := a.service.GetRecipeVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// a provider answering with no recipes is more likely broken than empty
+	if len(rids) == 0 && len(stored) > 0 {
+		return nil, errs.Unavailable(errors.New("no recipes on the provider, refusing to delete them all"))
+	}
+
+	var versions map[string]time.Time
+	if vp, ok := a.provider.(VersionProvider); ok {
+		versions, err = vp.GetRecipeVersions(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rec := &types.Reconciliation{
+		DryRun:  dryRun,
+		New:     []string{},
+		Deleted: []string{},
+		Updated: []string{},
+		Failed:  []string{},
+	}
+	onProvider := make(map[string]bool, len(rids))
+	for _, id := range rids {
+		onProvider[id] = true
+		modifiedAt, ok := stored[id]
+		switch {
+		case !ok:
+			rec.New = append(rec.New, id)
+		case versions[id].After(modifiedAt):
+			rec.Updated = append(rec.Updated, id)
+		}
+	}
+	for id := range stored {
+		if !onProvider[id] {
+			rec.Deleted = append(rec.Deleted, id)
+		}
+	}
+	sort.Strings(rec.New)
+	sort.Strings(rec.Deleted)
+	sort.Strings(rec.Updated)
+
+	log.Infof("reconciliation found %d new, %d deleted and %d updated recipe(s)", len(rec.New), len(rec.Deleted), len(rec.Updated))
+	if dryRun {
+		return rec, nil
+	}
+
+	send := func(ids []string, notify func(context.Context, string) error) {
+		for _, id := range ids {
+			err := notify(ctx, id)
+			if err != nil {
+				log.Errorf("error reconciling recipe ID %q: %s", id, err)
+				rec.Failed = append(rec.Failed, id)
+			}
+		}
+	}
+	send(rec.New, a.NewRecipe)
+	send(rec.Deleted, a.DeletedRecipe)
+	send(rec.Updated, a.UpdatedRecipe)
+
+	return rec, nil
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gospiga/pkg/errors"
+	"gospiga/pkg/events"
+	"gospiga/pkg/streamer"
+	"gospiga/pkg/types"
+)
+
+func (s *fakeService) GetRecipeVersions(ctx context.Context) (map[string]time.Time, error) {
+	return s.versions, nil
+}
+
+type versionedProvider struct {
+	*fakeProvider
+	versions map[string]time.Time
+}
+
+func (p *versionedProvider) GetRecipeVersions(ctx context.Context) (map[string]time.Time, error) {
+	return p.versions, nil
+}
+
+// sentProducer records the keys of the events sent, by stream.
+type sentProducer struct {
+	mu   sync.Mutex
+	sent map[string][]string
+}
+
+func (p *sentProducer) Add(ctx context.Context, stream string, msg *streamer.Message) error {
+	return p.Send(ctx, stream, msg)
+}
+
+func (p *sentProducer) Send(ctx context.Context, stream string, msg *streamer.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent[stream] = append(p.sent[stream], events.Key(*msg))
+	return nil
+}
+
+func TestReconcile(t *testing.T) {
+	t0 := time.Now()
+	tests := []struct {
+		name      string
+		ids       []string
+		idsErr    error
+		versioned bool
+		dryRun    bool
+		want      *types.Reconciliation
+		err       errors.Kind
+	}{
+		{
+			name:      "changes missed sent",
+			ids:       []string{"r3", "r1", "r2"},
+			versioned: true,
+			want: &types.Reconciliation{
+				New:     []string{"r3"},
+				Deleted: []string{"r4"},
+				Updated: []string{"r2"},
+				Failed:  []string{},
+			},
+		},
+		{
+			name:   "dry run without versions",
+			ids:    []string{"r3", "r1", "r2"},
+			dryRun: true,
+			want: &types.Reconciliation{
+				DryRun:  true,
+				New:     []string{"r3"},
+				Deleted: []string{"r4"},
+				Updated: []string{},
+				Failed:  []string{},
+			},
+		},
+		{
+			name: "empty provider",
+			err:  errors.KindUnavailable,
+		},
+		{
+			name:   "partial list from the provider",
+			ids:    []string{"r1"},
+			idsErr: errors.Unavailable(fmt.Errorf("got 1 recipes out of 3")),
+			err:    errors.KindUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			svc := &fakeService{versions: map[string]time.Time{
+				"r1": t0,
+				"r2": t0,
+				"r4": t0,
+			}}
+			var p Provider = &fakeProvider{ids: tt.ids, err: tt.idsErr}
+			if tt.versioned {
+				p = &versionedProvider{
+					fakeProvider: p.(*fakeProvider),
+					versions: map[string]time.Time{
+						"r1": t0.Add(-time.Hour),
+						"r2": t0.Add(time.Hour),
+						"r3": t0,
+					},
+				}
+			}
+			prod := &sentProducer{sent: make(map[string][]string)}
+			a := &app{service: svc, provider: p, producer: prod}
+
+			rec, err := a.Reconcile(context.Background(), tt.dryRun)
+			if tt.err != "" {
+				require.Equal(tt.err, errors.KindOf(err))
+				require.Empty(prod.sent)
+				return
+			}
+			require.NoError(err)
+			require.Equal(tt.want, rec)
+
+			if tt.dryRun {
+				require.Empty(prod.sent)
+				return
+			}
+			require.Equal(map[string][]string{
+				newRecipeStream:     rec.New,
+				deletedRecipeStream: rec.Deleted,
+				updatedRecipeStream: rec.Updated,
+			}, prod.sent)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+
+	"gospiga/pkg/streamer"
+)
+
+// DeadLetters lists the messages moved to the dead-letter stream of the given
+// stream.
+func (a *app) DeadLetters(ctx context.Context, stream string, count int64) ([]*streamer.DeadLetter, error) {
+	return a.streamer.DeadLetters(stream, count)
+}
+
+// DeadLetter returns a single dead-lettered message of the given stream.
+func (a *app) DeadLetter(ctx context.Context, stream, id string) (*streamer.DeadLetter, error) {
+	return a.streamer.GetDeadLetter(stream, id)
+}
+
+// Redrive sends a dead-lettered message back to its original stream.
+func (a *app) Redrive(ctx context.Context, stream, id string) (string, error) {
+	return a.streamer.Redrive(stream, id)
+}
+
+// ConsumerStats returns the stats of the consumer of the recipe streams.
+func (a *app) ConsumerStats(ctx context.Context) streamer.Stats {
+	return a.consumer.Stats()
+}
+
+// StreamsInfo reports the state of the given streams and of their consumer
+// groups, the streams read by the server by default.
+func (a *app) StreamsInfo(ctx context.Context, streams []string) ([]*streamer.StreamInfo, error) {
+	if len(streams) == 0 {
+		streams = a.consumer.Streams()
+	}
+	return a.streamer.Info(streams...)
+}
+
+// SetGroupID moves a consumer group on the stream to the given ID, the server
+// group by default, so that the entries after it are delivered again.
+func (a *app) SetGroupID(ctx context.Context, stream, group, id string) error {
+	if group == "" {
+		group = a.consumer.Group
+	}
+	return a.streamer.SetGroupID(stream, group, id)
+}
@@ -1,31 +1,64 @@
 package usecase
 
+import (
+	"context"
+	"sync"
+
+	"gospiga/pkg/log"
+	"gospiga/pkg/streamer"
+)
+
 type app struct {
 	service  Service
 	db       DB
 	streamer Streamer
+	producer Producer
+	jobs     JobStore
 	provider Provider
 	stub     Stub
-	shutdown chan struct{}
+	// ctx is done on shutdown
+	ctx      context.Context
+	shutdown context.CancelFunc
+	consumer *streamer.StreamArgs
+
+	mu sync.Mutex
+	// cancels the jobs running here, by job ID
+	cancels map[string]context.CancelFunc
 }
 
-func NewApp(service Service, db DB, streamer Streamer, provider Provider, stub Stub) *app {
+// NewApp returns the app, handling up to concurrency stream messages at the
+// same time.
+func NewApp(service Service, db DB, st Streamer, producer Producer, jobs JobStore, provider Provider, stub Stub, concurrency int) *app {
+	ctx, cancel := context.WithCancel(context.Background())
 	a := &app{
 		service:  service,
 		db:       db,
-		streamer: streamer,
+		streamer: st,
+		producer: producer,
+		jobs:     jobs,
 		provider: provider,
 		stub:     stub,
-		shutdown: make(chan struct{}),
+		ctx:      ctx,
+		shutdown: cancel,
+		cancels:  make(map[string]context.CancelFunc),
 	}
 
 	// start streamer to listen for new recipes.
-	go a.readRecipes()
+	err := a.readRecipes(ctx, concurrency)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// publish the changes to the recipes
+	go a.relayOutbox(ctx, outboxInterval)
+
+	// keep the streams bounded
+	go streamer.RunTrimmer(ctx, a.streamer, retention, trimInterval)
 
 	return a
 }
 
 // CloseGracefully sends the shutdown signal to start closing all app processes
 func (a *app) CloseGracefully() {
-	close(a.shutdown)
+	a.shutdown()
 }
@@ -2,7 +2,7 @@ VERSION = $(shell git describe --tags)
 GITREV = $(shell git rev-parse --verify --short HEAD)
 GITBRANCH = $(shell git rev-parse --abbrev-ref HEAD)
 DATE = $(shell LANG=US date +"%a, %d %b %Y %X %z")
-GOVERSION = 1.14.4
+GOVERSION = 1.20.12
 
 GO_LDFLAGS += -X 'gospiga.Version=$(VERSION)'
 GO_LDFLAGS += -X 'gospiga.GitRev=$(GITREV)'
@@ -15,3 +15,5 @@ DOCKER_TAG = $(notdir $(GITHUB_REF))
 endif
 
 DGRAPH_TAG = v20.03.3
+# dgraph is built with the toolchain of its release
+DGRAPH_GOVERSION = 1.14.4